@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/kr/pretty"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -24,6 +28,8 @@ type globalOptions struct {
 	HelmPath   string
 	Debug      bool
 	DryRun     bool
+	Backend    string
+	Timeout    time.Duration
 }
 
 // New returns root command object.
@@ -49,9 +55,15 @@ func New() *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&opts.SchemaFile, "schema", "s", "", "path to json schema")
 	cmd.PersistentFlags().BoolVar(&opts.Debug, "debug", false, "debug mode")
 	cmd.PersistentFlags().BoolVar(&opts.DryRun, "dry-run", false, "dry run mode")
+	cmd.PersistentFlags().StringVar(&opts.Backend, "backend", helm.BackendShell, "helm backend, shell to execute helm binary or sdk to use built-in helm")
+	cmd.PersistentFlags().DurationVar(&opts.Timeout, "timeout", 0, "maximum time of command running, e.g. 30m, not limited by default")
 
 	cmd.AddCommand(
-		newValidateCmd(opts), newInstallCmd(opts), newPlanCmd(opts))
+		newValidateCmd(opts), newInstallCmd(opts), newPlanCmd(opts),
+		newUninstallCmd(opts), newPruneCmd(opts), newDiffCmd(opts),
+		newTemplateCmd(opts), newStatusCmd(opts), newRollbackCmd(opts),
+		newLintCmd(opts), newListCmd(opts), newReposCmd(opts),
+		newLockCmd(opts), newPostRenderCmd())
 
 	return cmd
 }
@@ -90,6 +102,7 @@ type planOptions struct {
 	Environment string
 	ProjectID   string
 	Config      bool
+	Output      string
 }
 
 // plan write to stdout what releases with what params will be installed
@@ -101,6 +114,13 @@ type planOptions struct {
 // else -> print for all envs and prjs
 func plan(gopts *globalOptions, planOpts *planOptions) {
 	cfg := validate(gopts)
+	if planOpts.Output != "" {
+		if err := planPrint(cfg, planOpts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if planOpts.Config {
 		pretty.Println("Configuration Go object:")
 		pretty.Println(cfg)
@@ -168,7 +188,7 @@ func plan(gopts *globalOptions, planOpts *planOptions) {
 	}
 }
 
-// newValidateCmd returns new validate command.
+// newPlanCmd returns new plan command.
 func newPlanCmd(gopts *globalOptions) *cobra.Command {
 
 	planOpts := planOptions{}
@@ -185,6 +205,7 @@ func newPlanCmd(gopts *globalOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&planOpts.ProjectID, "project", "p", "", "GCP project id")
 	cmd.Flags().StringVarP(&planOpts.Release, "release", "r", "", "Release name")
 	cmd.Flags().BoolVar(&planOpts.Config, "config", false, "Dump configuration")
+	cmd.Flags().StringVarP(&planOpts.Output, "output", "o", "", "Output format: yaml or json")
 
 	return cmd
 }
@@ -194,7 +215,14 @@ type installOptions struct {
 	release        string
 	environment    string
 	projectID      string
+	prune          bool
+	parallel       int
+	releaseTimeout time.Duration
+	keepGoing      bool
+	report         string
+	reportFormat   string
 	helmClientOpts *helm.ShellClientOptions
+	backend        string
 	cfg            config.Config
 }
 
@@ -220,21 +248,35 @@ func newInstallCmd(gopts *globalOptions) *cobra.Command {
 				log.Fatal(err)
 			}
 			iopts.helmClientOpts.Debug = d
+			iopts.backend = gopts.Backend
+			iopts.helmClientOpts.HistoryFile = historyFile(gopts)
+			iopts.helmClientOpts.LockFile = lockFile(gopts)
 
 			if len(args) < 1 {
 				log.Fatal("Release is missing, please set release name or all")
 			}
 			iopts.release = args[0]
 			iopts.cfg = validate(gopts)
-			install(iopts)
+			ctx, stop, cancel := installContext(gopts)
+			defer cancel()
+			install(ctx, stop, iopts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&iopts.environment, "environment", "e", "", "environment name")
 	cmd.Flags().StringVarP(&iopts.projectID, "project", "p", "", "GCP project id")
-	cmd.Flags().StringVar(&helmClientOpts.SopsConfig, "sops-config", ".sops.yaml", "path to sops config")
+	cmd.Flags().BoolVar(&iopts.prune, "prune", false, "uninstall releases removed from target installs, requires all")
+	cmd.Flags().IntVar(&iopts.parallel, "parallel", 1, "number of independent releases installed at the same time by all")
+	cmd.Flags().BoolVar(&iopts.keepGoing, "keep-going", false, "install releases which do not need failed ones after a failure, requires all")
+	cmd.Flags().StringVar(&iopts.report, "report", "", "path to file with report of releases")
+	cmd.Flags().StringVar(&iopts.reportFormat, "report-format", helm.ReportJSON, "report format, json or junit")
+	cmd.Flags().DurationVar(&iopts.releaseTimeout, "release-timeout", 0, "maximum time of a release installing with scripts and helm, releaseTimeout of release overrides it, not limited by default")
+	cmd.Flags().BoolVar(&helmClientOpts.UpdateLock, "update-lock", false, "install charts which differ from helmctl.lock and update it")
+	cmd.Flags().StringVar(&helmClientOpts.SopsConfig, "sops-config", ".sops.yaml", "path to sops config, used only to warn about value files without creation rule")
 	cmd.Flags().BoolVar(&helmClientOpts.Diff, "diff", false, "show helm diff")
 	cmd.Flags().BoolVar(&helmClientOpts.SkipRepositories, "skip-repositories", false, "skip processing repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipReposUpdate, "skip-repos-update", false, "do not update indexes of present repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.ForceUpdate, "force-update", false, "add present repositories again")
 	cmd.Flags().BoolVar(&helmClientOpts.SkipScripts, "skip-scripts", false, "skip defined scripts")
 	cmd.Flags().BoolVar(&helmClientOpts.WithScripts, "with-scripts", false, "enable defined scripts")
 	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
@@ -242,7 +284,7 @@ func newInstallCmd(gopts *globalOptions) *cobra.Command {
 	return cmd
 }
 
-func install(iopts *installOptions) {
+func install(ctx context.Context, stop <-chan struct{}, iopts *installOptions) {
 	if iopts.environment != "" && iopts.projectID != "" {
 		log.Fatal("Only one target allowed, please set --project or --environment")
 	}
@@ -251,7 +293,19 @@ func install(iopts *installOptions) {
 		log.Fatal("No target, please set --project or --environment")
 	}
 
-	h, err := helm.NewShellClient(iopts.cfg, iopts.helmClientOpts)
+	if iopts.prune && iopts.release != "all" {
+		log.Fatal("Prune is allowed only with all releases")
+	}
+
+	if iopts.keepGoing && iopts.release != "all" {
+		log.Fatal("Keep going is allowed only with all releases")
+	}
+
+	if iopts.reportFormat != helm.ReportJSON && iopts.reportFormat != helm.ReportJUnit {
+		log.Fatalf("Unknown report format %s, json or junit are supported", iopts.reportFormat)
+	}
+
+	h, err := helm.NewClient(iopts.backend, iopts.cfg, iopts.helmClientOpts)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -259,13 +313,42 @@ func install(iopts *installOptions) {
 	in := helm.NewInstallOptions()
 	in.Release = iopts.release
 	in.Target = iopts.environment
+	in.Prune = iopts.prune
+	in.Parallel = iopts.parallel
+	in.KeepGoing = iopts.keepGoing
+	in.Stop = stop
+	in.ReleaseTimeout = iopts.releaseTimeout
 
 	if iopts.projectID != "" {
 		in.Target = iopts.projectID
 		in.TargetType = config.TargetProjects
 	}
 
-	if err := h.Install(in); err != nil {
+	if iopts.report != "" {
+		in.Report = helm.NewReport()
+	}
+
+	err = h.Install(ctx, in)
+	if iopts.report != "" {
+		if err := reportWrite(iopts.report, iopts.reportFormat, in.Report); err != nil {
+			log.Errorf("Failed to write report, %v", err)
+		}
+	}
+	if err != nil {
 		log.Fatalf("Failed to install release, %v", err)
 	}
 }
+
+// reportWrite writes install report to file.
+func reportWrite(path, format string, report *helm.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
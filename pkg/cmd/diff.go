@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// Define diff command exit codes
+const (
+	diffExitError   = 1
+	diffExitChanges = 2
+)
+
+// diffOptions contains values of defined flags for diff command.
+type diffOptions struct {
+	release        string
+	environment    string
+	projectID      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newDiffCmd returns new diff command.
+func newDiffCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+		Diff:   true,
+	}
+	dopts := &diffOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show difference between configured and installed releases.",
+		Long: `Show difference between configured and installed releases.
+
+Exit codes: 0 - no changes, 1 - error, 2 - there are changes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dopts.helmClientOpts.DryRun = gopts.DryRun
+			dopts.helmClientOpts.Debug = gopts.Debug
+			dopts.backend = gopts.Backend
+			dopts.helmClientOpts.LockFile = lockFile(gopts)
+
+			if len(args) < 1 {
+				log.Fatal("Release is missing, please set release name or all")
+			}
+			dopts.release = args[0]
+			dopts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			code := diff(ctx, dopts)
+			cancel()
+			os.Exit(code)
+		},
+	}
+
+	cmd.Flags().StringVarP(&dopts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&dopts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().StringVar(&helmClientOpts.SopsConfig, "sops-config", ".sops.yaml", "path to sops config, used only to warn about value files without creation rule")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipRepositories, "skip-repositories", false, "skip processing repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipReposUpdate, "skip-repos-update", false, "do not update indexes of present repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.ForceUpdate, "force-update", false, "add present repositories again")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+// diff prints helm diff with summary and returns exit code.
+func diff(ctx context.Context, dopts *diffOptions) int {
+	if dopts.environment != "" && dopts.projectID != "" {
+		log.Error("Only one target allowed, please set --project or --environment")
+		return diffExitError
+	}
+
+	if dopts.environment == "" && dopts.projectID == "" {
+		log.Error("No target, please set --project or --environment")
+		return diffExitError
+	}
+
+	h, err := helm.NewClient(dopts.backend, dopts.cfg, dopts.helmClientOpts)
+	if err != nil {
+		log.Error(err)
+		return diffExitError
+	}
+
+	in := helm.NewInstallOptions()
+	in.Release = dopts.release
+	in.Target = dopts.environment
+
+	if dopts.projectID != "" {
+		in.Target = dopts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	results, err := h.Diff(ctx, in)
+	if err != nil {
+		log.Errorf("Failed to diff release, %v", err)
+		return diffExitError
+	}
+
+	changes := false
+	for _, r := range results {
+		if r.Status == helm.DiffUnchanged {
+			continue
+		}
+		changes = true
+		fmt.Printf("Release %s:\n%s\n", r.Release, r.Output)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RELEASE\tNAMESPACE\tSTATUS")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Release, r.Namespace, r.Status)
+	}
+	w.Flush()
+
+	if changes {
+		return diffExitChanges
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// lintOptions contains values of defined flags for lint command.
+type lintOptions struct {
+	environment    string
+	projectID      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newLintCmd returns new lint command.
+func newLintCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	lopts := &lintOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Lint release charts with target values.",
+		Run: func(cmd *cobra.Command, args []string) {
+			lopts.helmClientOpts.DryRun = gopts.DryRun
+			lopts.helmClientOpts.Debug = gopts.Debug
+			lopts.backend = gopts.Backend
+			lopts.helmClientOpts.LockFile = lockFile(gopts)
+			lopts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			lint(ctx, lopts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&lopts.environment, "environment", "e", "", "environment name, all targets by default")
+	cmd.Flags().StringVarP(&lopts.projectID, "project", "p", "", "GCP project id, all targets by default")
+	cmd.Flags().StringVar(&helmClientOpts.SopsConfig, "sops-config", ".sops.yaml", "path to sops config, used only to warn about value files without creation rule")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipRepositories, "skip-repositories", false, "skip processing repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipReposUpdate, "skip-repos-update", false, "do not update indexes of present repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.ForceUpdate, "force-update", false, "add present repositories again")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func lint(ctx context.Context, lopts *lintOptions) {
+	if lopts.environment != "" && lopts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(lopts.backend, lopts.cfg, lopts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	targets := []*helm.LintOptions{}
+	switch {
+	case lopts.environment != "":
+		targets = append(targets, &helm.LintOptions{Target: lopts.environment, TargetType: config.TargetEnvironments})
+	case lopts.projectID != "":
+		targets = append(targets, &helm.LintOptions{Target: lopts.projectID, TargetType: config.TargetProjects})
+	default:
+		environments := lopts.cfg.Environments()
+		sort.Strings(environments)
+		for _, env := range environments {
+			targets = append(targets, &helm.LintOptions{Target: env, TargetType: config.TargetEnvironments})
+		}
+		projects := lopts.cfg.Projects()
+		sort.Strings(projects)
+		for _, prj := range projects {
+			targets = append(targets, &helm.LintOptions{Target: prj, TargetType: config.TargetProjects})
+		}
+	}
+
+	results := []*helm.LintResult{}
+	for _, in := range targets {
+		res, err := h.Lint(ctx, in)
+		if err != nil {
+			log.Fatalf("Failed to lint %s %s, %v", in.TargetType, in.Target, err)
+		}
+		results = append(results, res...)
+	}
+
+	failed := false
+	for _, r := range results {
+		if !r.Passed {
+			failed = true
+			fmt.Printf("Release %s (%s %s):\n%s\n", r.Release, r.TargetType, r.Target, r.Output)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tRELEASE\tRESULT")
+	for _, r := range results {
+		result := "passed"
+		if !r.Passed {
+			result = "failed"
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\n", r.TargetType, r.Target, r.Release, result)
+	}
+	w.Flush()
+
+	if failed {
+		log.Fatal("Linting failed")
+	}
+}
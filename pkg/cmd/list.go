@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// listOptions contains values of defined flags for list command.
+type listOptions struct {
+	output string
+}
+
+// listView contains list data prepared for different outputs.
+type listView struct {
+	header []string
+	rows   [][]string
+	data   interface{}
+}
+
+// newListCmd returns new list command.
+func newListCmd(gopts *globalOptions) *cobra.Command {
+	lopts := &listOptions{}
+
+	cmd := &cobra.Command{
+		Use:       "list environments|projects|releases|matrix",
+		Short:     "List environments, projects, releases or release versions by targets.",
+		ValidArgs: []string{"environments", "projects", "releases", "matrix"},
+		Args:      cobra.ExactValidArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := validate(gopts)
+			if err := list(cfg, args[0], lopts); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&lopts.output, "output", "o", "table", "output format: table, json or csv")
+
+	return cmd
+}
+
+func list(cfg config.Config, kind string, lopts *listOptions) error {
+	var view *listView
+	var err error
+
+	switch kind {
+	case "environments":
+		view = listTargets("ENVIRONMENT", cfg.Environments())
+	case "projects":
+		view = listTargets("PROJECT", cfg.Projects())
+	case "releases":
+		view = listReleases(cfg)
+	case "matrix":
+		view, err = listMatrix(cfg)
+	default:
+		err = fmt.Errorf("unknown list kind %s", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch lopts.output {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(view.header, "\t"))
+		for _, row := range view.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(view.header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(view.rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(view.data)
+	default:
+		return fmt.Errorf("unknown output format %s, table, json or csv are supported", lopts.output)
+	}
+}
+
+// listTargets returns view of sorted target names.
+func listTargets(header string, targets []string) *listView {
+	sort.Strings(targets)
+
+	view := &listView{header: []string{header}, rows: [][]string{}, data: targets}
+	for _, t := range targets {
+		view.rows = append(view.rows, []string{t})
+	}
+
+	return view
+}
+
+// listedRelease represents release in list output.
+type listedRelease struct {
+	Name      string `json:"name"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version"`
+	Namespace string `json:"namespace"`
+}
+
+// listReleases returns view of defined releases.
+func listReleases(cfg config.Config) *listView {
+	view := &listView{header: []string{"NAME", "CHART", "VERSION", "NAMESPACE"}, rows: [][]string{}}
+
+	releases := []*listedRelease{}
+	for _, r := range cfg.Releases() {
+		releases = append(releases, &listedRelease{r.Name, r.Chart, r.Version, r.Namespace})
+		view.rows = append(view.rows, []string{r.Name, r.Chart, matrixVersion(r.Version), r.Namespace})
+	}
+	view.data = releases
+
+	return view
+}
+
+// listMatrix returns view of resolved release versions by targets.
+// Not installed releases are marked with "-", releases without version with "latest".
+func listMatrix(cfg config.Config) (*listView, error) {
+	type target struct {
+		name       string
+		targetType config.TargetType
+	}
+
+	environments := cfg.Environments()
+	sort.Strings(environments)
+	projects := cfg.Projects()
+	sort.Strings(projects)
+
+	targets := []target{}
+	for _, env := range environments {
+		targets = append(targets, target{env, config.TargetEnvironments})
+	}
+	for _, prj := range projects {
+		targets = append(targets, target{prj, config.TargetProjects})
+	}
+
+	// versions by target type, target and release
+	data := map[config.TargetType]map[string]map[string]string{}
+	view := &listView{header: []string{"RELEASE"}, rows: [][]string{}, data: data}
+
+	for _, t := range targets {
+		releases, err := cfg.TargetReleases(t.name, t.targetType)
+		if err != nil {
+			return nil, err
+		}
+
+		if data[t.targetType] == nil {
+			data[t.targetType] = map[string]map[string]string{}
+		}
+		versions := map[string]string{}
+		for _, r := range releases {
+			versions[r.Name] = matrixVersion(r.Version)
+		}
+		data[t.targetType][t.name] = versions
+
+		view.header = append(view.header, t.name)
+	}
+
+	for _, r := range cfg.Releases() {
+		row := []string{r.Name}
+		for _, t := range targets {
+			version, ok := data[t.targetType][t.name][r.Name]
+			if !ok {
+				version = "-"
+			}
+			row = append(row, version)
+		}
+		view.rows = append(view.rows, row)
+	}
+
+	return view, nil
+}
+
+// matrixVersion returns version for output, empty version means the latest chart.
+func matrixVersion(version string) string {
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
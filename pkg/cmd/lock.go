@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// lockOptions contains values of defined flags for lock command.
+type lockOptions struct {
+	environment    string
+	projectID      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newLockCmd returns new lock command.
+func newLockCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	lopts := &lockOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Lock chart versions and digests of releases to helmctl.lock.",
+		Run: func(cmd *cobra.Command, args []string) {
+			lopts.helmClientOpts.Debug = gopts.Debug
+			lopts.helmClientOpts.LockFile = lockFile(gopts)
+			lopts.backend = gopts.Backend
+			lopts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			lock(ctx, lopts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&lopts.environment, "environment", "e", "", "environment name, all targets are locked by default")
+	cmd.Flags().StringVarP(&lopts.projectID, "project", "p", "", "GCP project id, all targets are locked by default")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func lock(ctx context.Context, lopts *lockOptions) {
+	if lopts.environment != "" && lopts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(lopts.backend, lopts.cfg, lopts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewLockOptions()
+	in.Target = lopts.environment
+
+	if lopts.projectID != "" {
+		in.Target = lopts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	if err := h.Lock(ctx, in); err != nil {
+		log.Fatalf("Failed to lock chart versions, %v", err)
+	}
+}
+
+// lockFile returns path of lock file next to config file.
+func lockFile(gopts *globalOptions) string {
+	return filepath.Join(filepath.Dir(gopts.ConfigFile), helm.DefaultLockFile)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// planOutput contains resolved releases keyed by target.
+type planOutput struct {
+	Environments map[string][]*config.Release `json:"environments,omitempty" yaml:"environments,omitempty"`
+	Projects     map[string][]*config.Release `json:"projects,omitempty" yaml:"projects,omitempty"`
+}
+
+// planPrint writes resolved releases to stdout in machine-readable format.
+func planPrint(cfg config.Config, planOpts *planOptions) error {
+	if planOpts.Output != "yaml" && planOpts.Output != "json" {
+		return fmt.Errorf("unknown output format %s, yaml or json are supported", planOpts.Output)
+	}
+
+	out, err := planResolve(cfg, planOpts)
+	if err != nil {
+		return err
+	}
+
+	if planOpts.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(out)
+}
+
+// planResolve resolves releases for targets selected by plan flags.
+func planResolve(cfg config.Config, planOpts *planOptions) (*planOutput, error) {
+	out := &planOutput{}
+
+	var environments, projects []string
+	switch {
+	case planOpts.Environment != "":
+		environments = []string{planOpts.Environment}
+	case planOpts.ProjectID != "":
+		projects = []string{planOpts.ProjectID}
+	default:
+		environments = cfg.Environments()
+		projects = cfg.Projects()
+	}
+	sort.Strings(environments)
+	sort.Strings(projects)
+
+	resolve := func(target string, targetType config.TargetType) ([]*config.Release, error) {
+		if planOpts.Release == "" {
+			return cfg.TargetReleases(target, targetType)
+		}
+		r, err := cfg.TargetRelease(planOpts.Release, target, targetType)
+		if err != nil {
+			return nil, err
+		}
+		return []*config.Release{r}, nil
+	}
+
+	for _, env := range environments {
+		releases, err := resolve(env, config.TargetEnvironments)
+		if err != nil {
+			return nil, err
+		}
+		if out.Environments == nil {
+			out.Environments = map[string][]*config.Release{}
+		}
+		out.Environments[env] = releases
+	}
+
+	for _, prj := range projects {
+		releases, err := resolve(prj, config.TargetProjects)
+		if err != nil {
+			return nil, err
+		}
+		if out.Projects == nil {
+			out.Projects = map[string][]*config.Release{}
+		}
+		out.Projects[prj] = releases
+	}
+
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// newPostRenderCmd returns new hidden command used by helm as post-renderer.
+// It reads rendered manifests from stdin and writes changed ones to stdout.
+func newPostRenderCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    helm.PostRenderCommand + " DEFINITION",
+		Short:  "Apply release post render definition to manifests from stdin.",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			postRender(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func postRender(definition string) {
+	// logs are written to stderr, stdout contains only manifests
+	log.SetOutput(os.Stderr)
+
+	pr := &config.PostRender{}
+	if err := json.Unmarshal([]byte(definition), pr); err != nil {
+		log.Fatalf("Cannot parse post render definition, %v", err)
+	}
+
+	renderer, err := helm.NewPostRenderer(pr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var in bytes.Buffer
+	if _, err := in.ReadFrom(os.Stdin); err != nil {
+		log.Fatal(err)
+	}
+
+	out, err := renderer.Run(&in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := out.WriteTo(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
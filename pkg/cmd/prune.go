@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// pruneOptions contains values of defined flags for prune command.
+type pruneOptions struct {
+	environment    string
+	projectID      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newPruneCmd returns new prune command.
+func newPruneCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	popts := &pruneOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Uninstall releases removed from target installs.",
+		Run: func(cmd *cobra.Command, args []string) {
+			popts.helmClientOpts.DryRun = gopts.DryRun
+			popts.helmClientOpts.Debug = gopts.Debug
+			popts.backend = gopts.Backend
+			popts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			prune(ctx, popts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&popts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&popts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func prune(ctx context.Context, popts *pruneOptions) {
+	if popts.environment != "" && popts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	if popts.environment == "" && popts.projectID == "" {
+		log.Fatal("No target, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(popts.backend, popts.cfg, popts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewPruneOptions()
+	in.Target = popts.environment
+
+	if popts.projectID != "" {
+		in.Target = popts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	if err := h.Prune(ctx, in); err != nil {
+		log.Fatalf("Failed to prune releases, %v", err)
+	}
+}
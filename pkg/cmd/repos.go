@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// reposOptions contains values of defined flags for repos command.
+type reposOptions struct {
+	action         string
+	environment    string
+	projectID      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newReposCmd returns new repos command.
+func newReposCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	ropts := &reposOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:       "repos sync|update|remove",
+		Short:     "Add missing, update or remove helm repositories of config and releases.",
+		ValidArgs: []string{"sync", "update", "remove"},
+		Args:      cobra.ExactValidArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ropts.helmClientOpts.DryRun = gopts.DryRun
+			ropts.helmClientOpts.Debug = gopts.Debug
+			ropts.backend = gopts.Backend
+			ropts.action = args[0]
+			ropts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			repos(ctx, ropts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&ropts.environment, "environment", "e", "", "environment name, repositories of all releases are used by default")
+	cmd.Flags().StringVarP(&ropts.projectID, "project", "p", "", "GCP project id, repositories of all releases are used by default")
+	cmd.Flags().BoolVar(&helmClientOpts.ForceUpdate, "force-update", false, "add present repositories again")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func repos(ctx context.Context, ropts *reposOptions) {
+	if ropts.environment != "" && ropts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(ropts.backend, ropts.cfg, ropts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewReposOptions()
+	in.Target = ropts.environment
+
+	if ropts.projectID != "" {
+		in.Target = ropts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	switch ropts.action {
+	case "sync":
+		err = h.ReposSync(ctx, in)
+	case "update":
+		err = h.ReposUpdate(ctx, in)
+	case "remove":
+		err = h.ReposRemove(ctx, in)
+	}
+	if err != nil {
+		log.Fatalf("Failed to %s helm repositories, %v", ropts.action, err)
+	}
+}
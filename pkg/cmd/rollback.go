@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// rollbackOptions contains values of defined flags for rollback command.
+type rollbackOptions struct {
+	release        string
+	environment    string
+	projectID      string
+	revision       int
+	uninstallNew   bool
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newRollbackCmd returns new rollback command.
+func newRollbackCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	ropts := &rollbackOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back release or releases installed by the last run.",
+		Run: func(cmd *cobra.Command, args []string) {
+			ropts.helmClientOpts.DryRun = gopts.DryRun
+			ropts.helmClientOpts.Debug = gopts.Debug
+			ropts.backend = gopts.Backend
+			ropts.helmClientOpts.HistoryFile = historyFile(gopts)
+
+			if len(args) < 1 {
+				log.Fatal("Release is missing, please set release name or all")
+			}
+			ropts.release = args[0]
+			ropts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			rollback(ctx, ropts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&ropts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&ropts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().IntVar(&ropts.revision, "to-revision", 0, "revision to roll back to, previous by default")
+	cmd.Flags().BoolVar(&ropts.uninstallNew, "uninstall-new", false, "uninstall releases installed for the first time by the last run")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func rollback(ctx context.Context, ropts *rollbackOptions) {
+	if ropts.environment != "" && ropts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	if ropts.environment == "" && ropts.projectID == "" {
+		log.Fatal("No target, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(ropts.backend, ropts.cfg, ropts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewRollbackOptions()
+	in.Release = ropts.release
+	in.Target = ropts.environment
+	in.Revision = ropts.revision
+	in.UninstallNew = ropts.uninstallNew
+
+	if ropts.projectID != "" {
+		in.Target = ropts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	if err := h.Rollback(ctx, in); err != nil {
+		log.Fatalf("Failed to roll back release, %v", err)
+	}
+}
+
+// historyFile returns path to history file placed next to config file.
+func historyFile(gopts *globalOptions) string {
+	return filepath.Join(filepath.Dir(gopts.ConfigFile), helm.DefaultHistoryFile)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// commandContext returns context cancelled by interrupt or global timeout.
+func commandContext(gopts *globalOptions) (context.Context, context.CancelFunc) {
+	ctx, cancel := timeoutContext(gopts)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// installContext returns context cancelled by the second interrupt, SIGTERM or
+// global timeout and channel closed by the first interrupt. After the first
+// interrupt running releases are finished and new ones are not started.
+func installContext(gopts *globalOptions) (context.Context, <-chan struct{}, context.CancelFunc) {
+	ctx, cancel := timeoutContext(gopts)
+	stop := make(chan struct{})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		stopped := false
+		for {
+			select {
+			case s := <-signals:
+				if s == os.Interrupt && !stopped {
+					log.Warn("Interrupted, finishing running releases, interrupt again to abort")
+					stopped = true
+					close(stop)
+					continue
+				}
+				log.Warn("Aborting")
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ctx, stop, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// timeoutContext returns context with global timeout, it is not limited if timeout is zero.
+func timeoutContext(gopts *globalOptions) (context.Context, context.CancelFunc) {
+	if gopts.Timeout > 0 {
+		return context.WithTimeout(context.Background(), gopts.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
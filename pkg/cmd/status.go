@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+	helmctlKubernetes "github.com/sprokhorov/helmctl/pkg/kubernetes"
+)
+
+// statusOptions contains values of defined flags for status command.
+type statusOptions struct {
+	environment string
+	projectID   string
+	kubeconfig  string
+	cfg         config.Config
+}
+
+// newStatusCmd returns new status command.
+func newStatusCmd(gopts *globalOptions) *cobra.Command {
+	sopts := &statusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show deployed and configured releases of target.",
+		Run: func(cmd *cobra.Command, args []string) {
+			sopts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			status(ctx, sopts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&sopts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&sopts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().StringVar(&sopts.kubeconfig, "kubeconfig", "", "path to kubeconfig, ~/.kube/config by default")
+
+	return cmd
+}
+
+func status(ctx context.Context, sopts *statusOptions) {
+	if sopts.environment != "" && sopts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	if sopts.environment == "" && sopts.projectID == "" {
+		log.Fatal("No target, please set --project or --environment")
+	}
+
+	target, targetType := sopts.environment, config.TargetEnvironments
+	if sopts.projectID != "" {
+		target, targetType = sopts.projectID, config.TargetProjects
+	}
+
+	releases, err := sopts.cfg.TargetReleases(target, targetType)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := helmctlKubernetes.GetKubernetesClient(sopts.kubeconfig)
+	if err != nil {
+		log.Fatalf("Cannot create Kubernetes client, %v", err)
+	}
+
+	statuses, err := helm.Status(ctx, client, releases)
+	if err != nil {
+		log.Fatalf("Failed to get releases status, %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RELEASE\tNAMESPACE\tCONFIGURED\tDEPLOYED\tAPP VERSION\tREVISION\tSTATUS\tPROBLEMS")
+	for _, s := range statuses {
+		revision := "-"
+		if s.Revision > 0 {
+			revision = fmt.Sprint(s.Revision)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Release, s.Namespace,
+			orDash(s.ConfiguredVersion), orDash(s.DeployedVersion), orDash(s.AppVersion),
+			revision, orDash(s.Status), orDash(strings.Join(s.Problems, ", ")))
+	}
+	w.Flush()
+}
+
+// orDash returns "-" for empty strings.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// templateOptions contains values of defined flags for template command.
+type templateOptions struct {
+	release        string
+	environment    string
+	projectID      string
+	outputDir      string
+	helmClientOpts *helm.ShellClientOptions
+	backend        string
+	cfg            config.Config
+}
+
+// newTemplateCmd returns new template command.
+func newTemplateCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	topts := &templateOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Render release manifests to directory.",
+		Run: func(cmd *cobra.Command, args []string) {
+			topts.helmClientOpts.DryRun = gopts.DryRun
+			topts.helmClientOpts.Debug = gopts.Debug
+			topts.backend = gopts.Backend
+			topts.helmClientOpts.LockFile = lockFile(gopts)
+
+			topts.release = "all"
+			if len(args) > 0 {
+				topts.release = args[0]
+			}
+			topts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			template(ctx, topts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&topts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&topts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().StringVarP(&topts.outputDir, "output-dir", "o", "rendered", "directory to write rendered manifests to")
+	cmd.Flags().StringVar(&helmClientOpts.SopsConfig, "sops-config", ".sops.yaml", "path to sops config, used only to warn about value files without creation rule")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipRepositories, "skip-repositories", false, "skip processing repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipReposUpdate, "skip-repos-update", false, "do not update indexes of present repositories")
+	cmd.Flags().BoolVar(&helmClientOpts.ForceUpdate, "force-update", false, "add present repositories again")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func template(ctx context.Context, topts *templateOptions) {
+	if topts.environment != "" && topts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	if topts.environment == "" && topts.projectID == "" {
+		log.Fatal("No target, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(topts.backend, topts.cfg, topts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewTemplateOptions()
+	in.Release = topts.release
+	in.Target = topts.environment
+	in.OutputDir = topts.outputDir
+
+	if topts.projectID != "" {
+		in.Target = topts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	if err := h.Template(ctx, in); err != nil {
+		log.Fatalf("Failed to render release, %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/helm"
+)
+
+// uninstallOptions contains values of defined flags for uninstall command.
+type uninstallOptions struct {
+	release         string
+	environment     string
+	projectID       string
+	deleteNamespace bool
+	helmClientOpts  *helm.ShellClientOptions
+	backend         string
+	cfg             config.Config
+}
+
+// newUninstallCmd returns new uninstall command.
+func newUninstallCmd(gopts *globalOptions) *cobra.Command {
+	helmClientOpts := &helm.ShellClientOptions{
+		Logger: log,
+	}
+	uopts := &uninstallOptions{helmClientOpts: helmClientOpts}
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Uninstall release.",
+		Run: func(cmd *cobra.Command, args []string) {
+			uopts.helmClientOpts.DryRun = gopts.DryRun
+			uopts.helmClientOpts.Debug = gopts.Debug
+			uopts.backend = gopts.Backend
+
+			if len(args) < 1 {
+				log.Fatal("Release is missing, please set release name or all")
+			}
+			uopts.release = args[0]
+			uopts.cfg = validate(gopts)
+			ctx, cancel := commandContext(gopts)
+			defer cancel()
+			uninstall(ctx, uopts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&uopts.environment, "environment", "e", "", "environment name")
+	cmd.Flags().StringVarP(&uopts.projectID, "project", "p", "", "GCP project id")
+	cmd.Flags().BoolVar(&uopts.deleteNamespace, "delete-namespace", false, "delete namespace created by helmctl")
+	cmd.Flags().BoolVar(&helmClientOpts.SkipScripts, "skip-scripts", false, "skip defined scripts")
+	cmd.Flags().BoolVar(&helmClientOpts.WithScripts, "with-scripts", false, "enable defined scripts")
+	cmd.Flags().StringVarP(&helmClientOpts.HelmPath, "helm", "H", "helm", "path to helm binary")
+
+	return cmd
+}
+
+func uninstall(ctx context.Context, uopts *uninstallOptions) {
+	if uopts.environment != "" && uopts.projectID != "" {
+		log.Fatal("Only one target allowed, please set --project or --environment")
+	}
+
+	if uopts.environment == "" && uopts.projectID == "" {
+		log.Fatal("No target, please set --project or --environment")
+	}
+
+	h, err := helm.NewClient(uopts.backend, uopts.cfg, uopts.helmClientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := helm.NewUninstallOptions()
+	in.Release = uopts.release
+	in.Target = uopts.environment
+	in.DeleteNamespace = uopts.deleteNamespace
+
+	if uopts.projectID != "" {
+		in.Target = uopts.projectID
+		in.TargetType = config.TargetProjects
+	}
+
+	if err := h.Uninstall(ctx, in); err != nil {
+		log.Fatalf("Failed to uninstall release, %v", err)
+	}
+}
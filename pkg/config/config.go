@@ -51,6 +51,7 @@ type Config interface {
     Releases() []*Release
     TargetRelease(name string, target string, targetType TargetType) (*Release, error)
     TargetReleases(target string, targetType TargetType) ([]*Release, error)
+    TargetHooks(target string, targetType TargetType) (*Hooks, error)
     Environments() []string
     Projects() []string
 }
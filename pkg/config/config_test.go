@@ -18,6 +18,8 @@ func TestConfig(t *testing.T) {
         "helmctl-duplicate-repositories.yaml":      "Duplicated repo something",
         "helmctl-duplicate-target-in-env.yaml":     "Duplicate Environment component name: origin-name",
         "helmctl-duplicate-target-in-project.yaml": "Duplicate Project component name: origin-name",
+        "helmctl-options-invalid.yaml":             "release origin-name: resetValues and reuseValues cannot be used together",
+        "helmctl-postrender-invalid.yaml":          "release origin-name: patch 0 of type json6902 must have target",
     }
 
     for file, errMsg := range files {
@@ -33,3 +35,104 @@ func TestConfig(t *testing.T) {
         t.Errorf("Config Test cannot load config file: %v", err)
     }
 }
+
+// TestTargetOptions tests that options are checked after merging with target params
+func TestTargetOptions(t *testing.T) {
+    log := logrus.New()
+
+    cfg := NewConfigFromFile(path.Join("testdata", "helmctl-options-target-invalid.yaml"), "", log, false)
+    errMsg := "environment development: release origin-name: resetValues and reuseValues cannot be used together"
+    if err := cfg.Load(); err == nil || err.Error() != errMsg {
+        t.Errorf("expected error %q, got %v", errMsg, err)
+    }
+}
+
+func TestTargetReleases(t *testing.T) {
+    log := logrus.New()
+
+    cfg := NewConfigFromFile(path.Join("testdata", "helmctl-targets.yaml"), "", log, false)
+    if err := cfg.Load(); err != nil {
+        t.Fatalf("cannot load config file: %v", err)
+    }
+
+    expected := map[string]struct {
+        version string
+        values  int
+    }{
+        "development": {"1.1.0", 2},
+        "production":  {"1.0.0", 2},
+    }
+
+    // resolve every target twice to make sure params are not accumulated
+    for i := 0; i < 2; i++ {
+        for env, exp := range expected {
+            releases, err := cfg.TargetReleases(env, TargetEnvironments)
+            if err != nil {
+                t.Fatalf("%s: %v", env, err)
+            }
+            if len(releases) != 1 {
+                t.Fatalf("%s: expected 1 release, got %d", env, len(releases))
+            }
+            r := releases[0]
+            if r.Version != exp.version {
+                t.Errorf("%s: expected version %s, got %s", env, exp.version, r.Version)
+            }
+            if len(r.Values) != exp.values {
+                t.Errorf("%s: expected %d values, got %d", env, exp.values, len(r.Values))
+            }
+        }
+    }
+
+    r, err := cfg.TargetRelease("origin-name", "qdoo-env-dev-01-567435", TargetProjects)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if r.Version != "1.0.0" || len(r.Values) != 1 {
+        t.Errorf("project release was modified by environments: %+v", r)
+    }
+}
+
+func TestNeeds(t *testing.T) {
+    log := logrus.New()
+
+    files := map[string]string{
+        "helmctl-needs-unknown.yaml": "release app needs unknown release ingress",
+        "helmctl-needs-cycle.yaml":   "release dependency cycle: app -> ingress -> cert-manager -> app",
+        // needs added by targets
+        "helmctl-needs-target-unknown.yaml": "environment development: release app needs unknown release ingress",
+        "helmctl-needs-target-cycle.yaml":   "project platform: release dependency cycle: app -> db -> app",
+    }
+
+    for file, errMsg := range files {
+        cfg := NewConfigFromFile(path.Join("testdata", file), "", log, false)
+        err := cfg.Load()
+        if err == nil || err.Error() != errMsg {
+            t.Errorf("%s: expected error %q, got %v", file, errMsg, err)
+        }
+    }
+
+    cfg := NewConfigFromFile(path.Join("testdata", "helmctl-needs.yaml"), "", log, false)
+    if err := cfg.Load(); err != nil {
+        t.Fatalf("cannot load config file: %v", err)
+    }
+
+    expected := map[string][]string{
+        "development": {"monitoring", "cert-manager", "ingress", "app"},
+        // needs of releases out of the target are ignored
+        "staging": {"app", "monitoring"},
+    }
+
+    for env, order := range expected {
+        releases, err := cfg.TargetReleases(env, TargetEnvironments)
+        if err != nil {
+            t.Fatalf("%s: %v", env, err)
+        }
+        names := []string{}
+        for _, r := range releases {
+            names = append(names, r.Name)
+        }
+        if strings.Join(names, ",") != strings.Join(order, ",") {
+            t.Errorf("%s: expected order %v, got %v", env, order, names)
+        }
+    }
+}
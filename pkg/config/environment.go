@@ -2,8 +2,6 @@ package config
 
 import (
     "fmt"
-
-    "github.com/mitchellh/mapstructure"
 )
 
 // Environment interface type is used to hide different kind of envs
@@ -54,7 +52,7 @@ func (e *EnvironmentComplex) GetValues() *Release {
 
 // SetValues set values
 func (e *EnvironmentComplex) SetValues(values map[string]interface{}) error {
-    err := mapstructure.Decode(values, &e.r)
+    err := decode(values, &e.r)
     if err != nil {
         return fmt.Errorf("Cannot decode environment into Release: %v", err)
     }
@@ -62,6 +60,9 @@ func (e *EnvironmentComplex) SetValues(values map[string]interface{}) error {
     if err = e.r.checkScripts(); err != nil {
         return fmt.Errorf("Non valid paths for files in  environment: %v", err)
     }
+    if err = e.r.checkOptions(); err != nil {
+        return err
+    }
     return nil
 }
 
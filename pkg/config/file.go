@@ -3,9 +3,9 @@ package config
 import (
     "fmt"
     "path/filepath"
+    "sort"
 
     "github.com/imdario/mergo"
-    "github.com/mitchellh/mapstructure"
     "github.com/sirupsen/logrus"
 )
 
@@ -32,6 +32,7 @@ type File struct {
         Repositories []*Repository
         Releases     []*Release
         Installs     Installs
+        Hooks        SpecHooks
     }
 
     l          *logrus.Logger
@@ -58,7 +59,7 @@ func (cf *File) Load() (err error) {
 
     if repos, ok := spec["repositories"].([]interface{}); ok {
         // Decoding parsed file into Go struct
-        err = mapstructure.Decode(repos, &cf.Spec.Repositories)
+        err = decode(repos, &cf.Spec.Repositories)
         if err != nil {
             return fmt.Errorf("%s: %v", cf.configFile, err)
         }
@@ -78,7 +79,7 @@ func (cf *File) Load() (err error) {
     }
 
     if releases, ok := spec["releases"].([]interface{}); ok {
-        err = mapstructure.Decode(releases, &cf.Spec.Releases)
+        err = decode(releases, &cf.Spec.Releases)
         if err != nil {
             return fmt.Errorf("%s: %v", cf.configFile, err)
         }
@@ -94,6 +95,13 @@ func (cf *File) Load() (err error) {
         }
     }
 
+    if hooks, ok := spec["hooks"].(map[string]interface{}); ok {
+        err = decode(hooks, &cf.Spec.Hooks)
+        if err != nil {
+            return fmt.Errorf("%s: %v", cf.configFile, err)
+        }
+    }
+
     if installs, ok := spec["installs"].(map[string]interface{}); ok {
 
         // Process Environments
@@ -101,7 +109,7 @@ func (cf *File) Load() (err error) {
             cf.Spec.Installs.Environments = make(map[string][]*Environment)
 
             var newEnvironmentValues map[string][]interface{}
-            err = mapstructure.Decode(environments, &newEnvironmentValues)
+            err = decode(environments, &newEnvironmentValues)
             if err != nil {
                 return fmt.Errorf("%s: %v", cf.configFile, err)
             }
@@ -150,7 +158,7 @@ func (cf *File) Load() (err error) {
             cf.Spec.Installs.Projects = make(map[string][]*Project)
 
             var newProjectValues map[string][]interface{}
-            err = mapstructure.Decode(projects, &newProjectValues)
+            err = decode(projects, &newProjectValues)
             if err != nil {
                 return fmt.Errorf("%s: %v", cf.configFile, err)
             }
@@ -178,7 +186,7 @@ func (cf *File) Load() (err error) {
                                 cf.l.Errorf("Cannot find name for project: %v\n", project)
                             } else {
                                 newProject := NewProjectComplex(name.(string))
-                                newProject.SetValues(project)
+                                err = newProject.SetValues(project)
                                 if err != nil {
                                     return fmt.Errorf("Cannot parse project additional variables as Release: %s: %v", cf.configFile, err)
                                 }
@@ -207,13 +215,29 @@ func (cf *File) Load() (err error) {
             return fmt.Errorf("invalid script, %v", err)
         }
 
+        if err := r.checkOptions(); err != nil {
+            return err
+        }
+
         r.setDefaults()
     }
 
+    if err := cf.Spec.Hooks.prepare(); err != nil {
+        return fmt.Errorf("invalid hook, %v", err)
+    }
+
     if err := cf.checkInstallations(); err != nil {
         return err
     }
 
+    if err := checkNeeds(cf.Spec.Releases, cf.Spec.Releases); err != nil {
+        return err
+    }
+
+    if err := cf.checkTargetReleases(); err != nil {
+        return err
+    }
+
     return nil
 }
 
@@ -234,11 +258,12 @@ func (cf *File) mergeReleaseParams(targetRelease *Release, targetName string, ta
         {
             for _, release := range cf.Spec.Installs.Projects[targetName] {
                 if (*release).GetName() == (*targetRelease).Name {
-                    values := (*release).GetValues()
+                    values := (*release).GetValues().copy()
                     if err := mergo.Merge(
                         targetRelease, values,
                         mergo.WithAppendSlice,
-                        mergo.WithOverride); err != nil {
+                        mergo.WithOverride,
+                        mergo.WithTransformers(optionsTransformer{})); err != nil {
                         return fmt.Errorf("Unexpected internal error during merging project params: %v", err)
                     }
                     return nil
@@ -250,11 +275,12 @@ func (cf *File) mergeReleaseParams(targetRelease *Release, targetName string, ta
         {
             for _, release := range cf.Spec.Installs.Environments[targetName] {
                 if (*release).GetName() == (*targetRelease).Name {
-                    values := (*release).GetValues()
+                    values := (*release).GetValues().copy()
                     if err := mergo.Merge(
                         targetRelease, values,
                         mergo.WithAppendSlice,
-                        mergo.WithOverride); err != nil {
+                        mergo.WithOverride,
+                        mergo.WithTransformers(optionsTransformer{})); err != nil {
                         return fmt.Errorf("Unexpected internal error during merging project params: %v", err)
                     }
                     return nil
@@ -276,14 +302,19 @@ func (cf *File) ReleaseGet(name string) (*Release, error) {
     return nil, fmt.Errorf("release %s not found", name)
 }
 
-// TargetRelease returns releases associated to the target
+// TargetRelease returns releases associated to the target.
+// Returned release is a copy of the defined one merged with target params.
 func (cf *File) TargetRelease(name string, target string, targetType TargetType) (*Release, error) {
-    for _, r := range cf.Spec.Releases {
-        if r.Name == name {
+    for _, release := range cf.Spec.Releases {
+        if release.Name == name {
+            r := release.copy()
             if err := cf.mergeReleaseParams(r, target, targetType); err != nil {
                 return r, err
             }
             r.setDefaults()
+            if err := r.checkOptions(); err != nil {
+                return nil, err
+            }
             return r, nil
         }
     }
@@ -291,6 +322,8 @@ func (cf *File) TargetRelease(name string, target string, targetType TargetType)
 }
 
 // TargetReleases returns releases associated to the target.
+// Returned releases are copies of the defined ones merged with target params
+// sorted in dependency order.
 func (cf *File) TargetReleases(target string, targetType TargetType) ([]*Release, error) {
     switch targetType {
     case TargetProjects:
@@ -309,17 +342,21 @@ func (cf *File) TargetReleases(target string, targetType TargetType) ([]*Release
             i := 0
             releases := make([]*Release, len(targets[target]))
 
-            for _, r := range cf.Spec.Releases {
-                if _, indexed := idx[r.Name]; indexed {
+            for _, release := range cf.Spec.Releases {
+                if _, indexed := idx[release.Name]; indexed {
+                    r := release.copy()
                     if err := cf.mergeReleaseParams(r, target, targetType); err != nil {
                         return []*Release{}, fmt.Errorf("Unexpected internal error during merging project params: %v", err)
                     }
                     r.setDefaults()
+                    if err := r.checkOptions(); err != nil {
+                        return []*Release{}, err
+                    }
                     releases[i] = r
                     i++
                 }
             }
-            return releases, nil
+            return sortReleases(releases)
         }
 
     case TargetEnvironments:
@@ -338,18 +375,22 @@ func (cf *File) TargetReleases(target string, targetType TargetType) ([]*Release
             i := 0
             releases := make([]*Release, len(targets[target]))
 
-            for _, r := range cf.Spec.Releases {
-                if _, indexed := idx[r.Name]; indexed {
+            for _, release := range cf.Spec.Releases {
+                if _, indexed := idx[release.Name]; indexed {
+                    r := release.copy()
                     if err := cf.mergeReleaseParams(r, target, targetType); err != nil {
                         return []*Release{}, fmt.Errorf("Unexpected internal error during merging environment params: %v", err)
                     }
                     r.setDefaults()
+                    if err := r.checkOptions(); err != nil {
+                        return []*Release{}, err
+                    }
                     releases[i] = r
                     i++
                 }
             }
 
-            return releases, nil
+            return sortReleases(releases)
 
         }
     default:
@@ -357,6 +398,34 @@ func (cf *File) TargetReleases(target string, targetType TargetType) ([]*Release
     }
 }
 
+// checkTargetReleases checks releases merged with params of targets, targets
+// could add needs and change options of releases.
+func (cf *File) checkTargetReleases() error {
+    targets := []struct {
+        kind       string
+        names      []string
+        targetType TargetType
+    }{
+        {"environment", cf.Environments(), TargetEnvironments},
+        {"project", cf.Projects(), TargetProjects},
+    }
+
+    for _, t := range targets {
+        sort.Strings(t.names)
+        for _, name := range t.names {
+            releases, err := cf.TargetReleases(name, t.targetType)
+            if err == nil {
+                err = checkNeeds(releases, cf.Spec.Releases)
+            }
+            if err != nil {
+                return fmt.Errorf("%s %s: %v", t.kind, name, err)
+            }
+        }
+    }
+
+    return nil
+}
+
 func (cf *File) checkInstallations() error {
     for env, releases := range cf.Spec.Installs.Environments {
         for _, r := range releases {
@@ -8,8 +8,11 @@ import (
 
 // ValueFile represents helm value file.
 type ValueFile struct {
-    Name    string
-    Decrypt *bool
+    Name    string `json:"name" yaml:"name"`
+    Decrypt *bool  `json:"decrypt" yaml:"decrypt"`
+    // Format of encrypted file: yaml, json, dotenv, ini or binary.
+    // It is detected by file extension if empty.
+    Format string `json:"format,omitempty" yaml:"format,omitempty"`
 }
 
 func (vf *ValueFile) GetDecrypt() bool {
@@ -28,9 +31,9 @@ func (vf *ValueFile) setDefaults() {
 
 // Value represents helm value. This value will be setted via --set argument to helm.
 type Value struct {
-    Name  string
-    Value interface{}
-    Type  string
+    Name  string      `json:"name" yaml:"name"`
+    Value interface{} `json:"value" yaml:"value"`
+    Type  string      `json:"type,omitempty" yaml:"type,omitempty"`
 }
 
 func (v *Value) GetKeyValuePair() string {
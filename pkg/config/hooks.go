@@ -0,0 +1,88 @@
+package config
+
+import "fmt"
+
+// Hooks represents scripts executed once around installing of all releases
+// of environment or project.
+type Hooks struct {
+    // BeforeAll scripts are executed before installing of releases.
+    BeforeAll []*Script `json:"beforeAll" yaml:"beforeAll"`
+    // AfterAll scripts are executed after successful installing of releases.
+    AfterAll []*Script `json:"afterAll" yaml:"afterAll"`
+    // OnFailure scripts are executed if installing of releases failed.
+    OnFailure []*Script `json:"onFailure" yaml:"onFailure"`
+}
+
+// SpecHooks represents global hooks and hooks of environments and projects.
+type SpecHooks struct {
+    Hooks `mapstructure:",squash"`
+
+    Environments map[string]*Hooks `json:"environments" yaml:"environments"`
+    Projects     map[string]*Hooks `json:"projects" yaml:"projects"`
+}
+
+// scripts returns all scripts of hooks.
+func (h *Hooks) scripts() []*Script {
+    scripts := []*Script{}
+    for _, list := range [][]*Script{h.BeforeAll, h.AfterAll, h.OnFailure} {
+        scripts = append(scripts, list...)
+    }
+    return scripts
+}
+
+// merge returns hooks with scripts of h followed by scripts of other.
+func (h *Hooks) merge(other *Hooks) *Hooks {
+    merged := &Hooks{
+        BeforeAll: copyScripts(h.BeforeAll),
+        AfterAll:  copyScripts(h.AfterAll),
+        OnFailure: copyScripts(h.OnFailure),
+    }
+    if other != nil {
+        merged.BeforeAll = append(merged.BeforeAll, copyScripts(other.BeforeAll)...)
+        merged.AfterAll = append(merged.AfterAll, copyScripts(other.AfterAll)...)
+        merged.OnFailure = append(merged.OnFailure, copyScripts(other.OnFailure)...)
+    }
+    return merged
+}
+
+// prepare updates script paths and checks scripts of all hooks.
+func (sh *SpecHooks) prepare() error {
+    all := []*Hooks{&sh.Hooks}
+    for _, h := range sh.Environments {
+        all = append(all, h)
+    }
+    for _, h := range sh.Projects {
+        all = append(all, h)
+    }
+
+    for _, h := range all {
+        if h == nil {
+            continue
+        }
+        for _, s := range h.scripts() {
+            s.pathUpdate("")
+            if err := s.check(); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// TargetHooks returns global hooks followed by hooks of the target.
+func (cf *File) TargetHooks(target string, targetType TargetType) (*Hooks, error) {
+    switch targetType {
+    case TargetEnvironments:
+        if _, exists := cf.Spec.Installs.Environments[target]; !exists {
+            return nil, fmt.Errorf("unknown target %s", target)
+        }
+        return cf.Spec.Hooks.merge(cf.Spec.Hooks.Environments[target]), nil
+    case TargetProjects:
+        if _, exists := cf.Spec.Installs.Projects[target]; !exists {
+            return nil, fmt.Errorf("unknown target %s", target)
+        }
+        return cf.Spec.Hooks.merge(cf.Spec.Hooks.Projects[target]), nil
+    default:
+        return nil, fmt.Errorf("unknown target type %s", targetType)
+    }
+}
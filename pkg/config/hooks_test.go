@@ -0,0 +1,49 @@
+package config
+
+import (
+    "path"
+    "testing"
+
+    "github.com/sirupsen/logrus"
+)
+
+func TestTargetHooks(t *testing.T) {
+    cfg := NewConfigFromFile(path.Join("testdata", "helmctl-hooks.yaml"), "", logrus.New(), false)
+    if err := cfg.Load(); err != nil {
+        t.Fatalf("cannot load config file: %v", err)
+    }
+
+    expected := map[string][]string{
+        "development": {"echo global failure", "echo development failure"},
+        "production":  {"echo global failure"},
+    }
+
+    // resolve every target twice to make sure hooks are not accumulated
+    for i := 0; i < 2; i++ {
+        for env, exp := range expected {
+            hooks, err := cfg.TargetHooks(env, TargetEnvironments)
+            if err != nil {
+                t.Fatalf("%s: %v", env, err)
+            }
+            if len(hooks.BeforeAll) != 1 {
+                t.Errorf("%s: expected 1 beforeAll hook, got %d", env, len(hooks.BeforeAll))
+            }
+            got := []string{}
+            for _, s := range hooks.OnFailure {
+                got = append(got, s.Run)
+            }
+            if len(got) != len(exp) {
+                t.Fatalf("%s: expected onFailure hooks %v, got %v", env, exp, got)
+            }
+            for idx := range exp {
+                if got[idx] != exp[idx] {
+                    t.Errorf("%s: expected onFailure hooks %v, got %v", env, exp, got)
+                }
+            }
+        }
+    }
+
+    if _, err := cfg.TargetHooks("unknown", TargetEnvironments); err == nil {
+        t.Error("expected error for unknown target")
+    }
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+    "fmt"
+    "strings"
+)
+
+// checkNeeds checks that releases need only defined releases and there are no cycles.
+func checkNeeds(releases []*Release, defined []*Release) error {
+    idx := make(map[string]struct{}, len(defined))
+    for _, r := range defined {
+        idx[r.Name] = struct{}{}
+    }
+
+    for _, r := range releases {
+        for _, need := range r.Needs {
+            if _, exists := idx[need]; !exists {
+                return fmt.Errorf("release %s needs unknown release %s", r.Name, need)
+            }
+        }
+    }
+
+    if cycle := findCycle(releases); cycle != nil {
+        return fmt.Errorf("release dependency cycle: %s", strings.Join(cycle, " -> "))
+    }
+
+    return nil
+}
+
+// findCycle returns dependency cycle path or nil. Needs of releases
+// out of the list are ignored.
+func findCycle(releases []*Release) []string {
+    byName := make(map[string]*Release, len(releases))
+    for _, r := range releases {
+        byName[r.Name] = r
+    }
+
+    const (
+        unvisited = iota
+        visiting
+        visited
+    )
+    state := make(map[string]int, len(releases))
+    path := []string{}
+
+    var visit func(r *Release) []string
+    visit = func(r *Release) []string {
+        state[r.Name] = visiting
+        path = append(path, r.Name)
+
+        for _, need := range r.Needs {
+            n, exists := byName[need]
+            if !exists {
+                continue
+            }
+            switch state[need] {
+            case visiting:
+                // cycle starts from the first occurrence of need in path
+                for i, name := range path {
+                    if name == need {
+                        return append(append([]string{}, path[i:]...), need)
+                    }
+                }
+            case unvisited:
+                if cycle := visit(n); cycle != nil {
+                    return cycle
+                }
+            }
+        }
+
+        path = path[:len(path)-1]
+        state[r.Name] = visited
+        return nil
+    }
+
+    for _, r := range releases {
+        if state[r.Name] == unvisited {
+            if cycle := visit(r); cycle != nil {
+                return cycle
+            }
+        }
+    }
+
+    return nil
+}
+
+// sortReleases returns releases in dependency order. Order of independent
+// releases is kept. Needs of releases out of the list are ignored.
+func sortReleases(releases []*Release) ([]*Release, error) {
+    if cycle := findCycle(releases); cycle != nil {
+        return nil, fmt.Errorf("release dependency cycle: %s", strings.Join(cycle, " -> "))
+    }
+
+    listed := make(map[string]struct{}, len(releases))
+    for _, r := range releases {
+        listed[r.Name] = struct{}{}
+    }
+
+    sorted := make([]*Release, 0, len(releases))
+    placed := make(map[string]struct{}, len(releases))
+
+    for len(sorted) < len(releases) {
+        for _, r := range releases {
+            if _, done := placed[r.Name]; done {
+                continue
+            }
+            ready := true
+            for _, need := range r.Needs {
+                _, inList := listed[need]
+                _, done := placed[need]
+                if inList && !done {
+                    ready = false
+                    break
+                }
+            }
+            if ready {
+                sorted = append(sorted, r)
+                placed[r.Name] = struct{}{}
+                break
+            }
+        }
+    }
+
+    return sorted, nil
+}
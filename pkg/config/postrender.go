@@ -0,0 +1,108 @@
+package config
+
+import (
+    "fmt"
+    "path/filepath"
+)
+
+// Define patch types
+const (
+    PatchStrategicMerge = "strategicMerge"
+    PatchJSON6902       = "json6902"
+)
+
+// PostRender represents changes applied to rendered manifests of release
+// before they are installed.
+type PostRender struct {
+    // Labels are added to metadata of all resources.
+    Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+    // Annotations are added to metadata of all resources.
+    Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+    // Patches are applied to matching resources in order of definition.
+    Patches []*Patch `json:"patches,omitempty" yaml:"patches,omitempty"`
+}
+
+// Patch represents strategic merge or JSON6902 patch defined inline or in a file.
+type Patch struct {
+    // Type is strategicMerge (default) or json6902.
+    Type string `json:"type,omitempty" yaml:"type,omitempty"`
+    // Patch is inline patch in YAML or JSON format.
+    Patch string `json:"patch,omitempty" yaml:"patch,omitempty"`
+    // Path of file with patch.
+    Path string `json:"path,omitempty" yaml:"path,omitempty"`
+    // Target selects patched resources, strategic merge patch is applied to
+    // the resource with its kind and name if target is not set.
+    Target *PatchTarget `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+// PatchTarget selects resources by kind, name and namespace, empty fields match any value.
+type PatchTarget struct {
+    Kind      string `json:"kind,omitempty" yaml:"kind,omitempty"`
+    Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+    Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// GetType returns patch type, strategic merge is default one.
+func (p *Patch) GetType() string {
+    if p.Type == "" {
+        return PatchStrategicMerge
+    }
+    return p.Type
+}
+
+// pathUpdate prepends path of config file to relative patch paths.
+func (pr *PostRender) pathUpdate(includePath string) {
+    for _, p := range pr.Patches {
+        if p.Path != "" && !filepath.IsAbs(p.Path) {
+            p.Path = filepath.Join(ConfigFilePath, filepath.Dir(includePath), p.Path)
+        }
+    }
+}
+
+// check checks definitions of patches.
+func (pr *PostRender) check() error {
+    for idx, p := range pr.Patches {
+        if (p.Path == "") == (p.Patch == "") {
+            return fmt.Errorf("patch %d must have either path or patch", idx)
+        }
+        switch p.GetType() {
+        case PatchStrategicMerge:
+        case PatchJSON6902:
+            if p.Target == nil {
+                return fmt.Errorf("patch %d of type %s must have target", idx, PatchJSON6902)
+            }
+        default:
+            return fmt.Errorf("patch %d has unknown type %s", idx, p.Type)
+        }
+    }
+    return nil
+}
+
+// copy returns deep copy of post render definition.
+func (pr *PostRender) copy() *PostRender {
+    c := &PostRender{}
+    if pr.Labels != nil {
+        c.Labels = make(map[string]string, len(pr.Labels))
+        for k, v := range pr.Labels {
+            c.Labels[k] = v
+        }
+    }
+    if pr.Annotations != nil {
+        c.Annotations = make(map[string]string, len(pr.Annotations))
+        for k, v := range pr.Annotations {
+            c.Annotations[k] = v
+        }
+    }
+    if pr.Patches != nil {
+        c.Patches = make([]*Patch, len(pr.Patches))
+        for idx, p := range pr.Patches {
+            patch := *p
+            if p.Target != nil {
+                target := *p.Target
+                patch.Target = &target
+            }
+            c.Patches[idx] = &patch
+        }
+    }
+    return c
+}
@@ -2,8 +2,6 @@ package config
 
 import (
     "fmt"
-
-    "github.com/mitchellh/mapstructure"
 )
 
 // Project interface
@@ -54,7 +52,7 @@ func (e *ProjectComplex) GetValues() *Release {
 
 // SetValues set alues for complex project
 func (e *ProjectComplex) SetValues(values map[string]interface{}) error {
-    err := mapstructure.Decode(values, &e.r)
+    err := decode(values, &e.r)
     if err != nil {
         return fmt.Errorf("Cannot decode project into Release: %v", err)
     }
@@ -62,6 +60,9 @@ func (e *ProjectComplex) SetValues(values map[string]interface{}) error {
     if err = e.r.checkScripts(); err != nil {
         return fmt.Errorf("Non valid paths for files in  project: %v", err)
     }
+    if err = e.r.checkOptions(); err != nil {
+        return err
+    }
     return nil
 }
 
@@ -1,40 +1,59 @@
 package config
 
 import (
+    "fmt"
     "os"
     "path/filepath"
+    "reflect"
+    "time"
 )
 
 // Release represents helm release with values.
 type Release struct {
-    Name          string
-    Chart         string
-    Version       string
-    Namespace     string
-    BeforeScripts []*string
-    AfterScripts  []*string
-    Atomic        *bool
-    Repository    *Repository
-    Values        []*Value
-    ValueFiles    []*ValueFile
-
-    IncludePath string
+    Name                   string       `json:"name" yaml:"name"`
+    Chart                  string       `json:"chart" yaml:"chart"`
+    Version                string       `json:"version" yaml:"version"`
+    Namespace              string       `json:"namespace" yaml:"namespace"`
+    BeforeScripts          []*Script    `json:"beforeScripts" yaml:"beforeScripts"`
+    AfterScripts           []*Script    `json:"afterScripts" yaml:"afterScripts"`
+    OnFailureScripts       []*Script    `json:"onFailureScripts" yaml:"onFailureScripts"`
+    BeforeUninstallScripts []*Script    `json:"beforeUninstallScripts" yaml:"beforeUninstallScripts"`
+    AfterUninstallScripts  []*Script    `json:"afterUninstallScripts" yaml:"afterUninstallScripts"`
+    Atomic                 *bool        `json:"atomic" yaml:"atomic"`
+    Wait                   *bool        `json:"wait" yaml:"wait"`
+    WaitForJobs            *bool        `json:"waitForJobs" yaml:"waitForJobs"`
+    Timeout                string       `json:"timeout" yaml:"timeout"`
+    ReleaseTimeout         string       `json:"releaseTimeout" yaml:"releaseTimeout"`
+    Force                  *bool        `json:"force" yaml:"force"`
+    CleanupOnFail          *bool        `json:"cleanupOnFail" yaml:"cleanupOnFail"`
+    SkipCRDs               *bool        `json:"skipCRDs" yaml:"skipCRDs"`
+    MaxHistory             *int         `json:"maxHistory" yaml:"maxHistory"`
+    Description            string       `json:"description" yaml:"description"`
+    ResetValues            *bool        `json:"resetValues" yaml:"resetValues"`
+    ReuseValues            *bool        `json:"reuseValues" yaml:"reuseValues"`
+    DisableHooks           *bool        `json:"disableHooks" yaml:"disableHooks"`
+    ExtraArgs              []string     `json:"extraArgs" yaml:"extraArgs"`
+    PostRender             *PostRender  `json:"postRender" yaml:"postRender"`
+    Repository             *Repository  `json:"repository" yaml:"repository"`
+    Values                 []*Value     `json:"values" yaml:"values"`
+    ValueFiles             []*ValueFile `json:"valueFiles" yaml:"valueFiles"`
+    Needs                  []string     `json:"needs" yaml:"needs"`
+
+    IncludePath string `json:"-" yaml:"-"`
 }
 
 // pathAppend appends path prefix to scripts and value-files.
 func (r *Release) pathUpdate() {
-    for idx, s := range r.BeforeScripts {
-        realPath := filepath.Join(ConfigFilePath, filepath.Dir(r.IncludePath), *s)
-        r.BeforeScripts[idx] = &realPath
-    }
-    for idx, s := range r.AfterScripts {
-        realPath := filepath.Join(ConfigFilePath, filepath.Dir(r.IncludePath), *s)
-        r.AfterScripts[idx] = &realPath
+    for _, s := range r.scripts() {
+        s.pathUpdate(r.IncludePath)
     }
     for idx, vf := range r.ValueFiles {
         realPath := filepath.Join(ConfigFilePath, filepath.Dir(r.IncludePath), vf.Name)
         r.ValueFiles[idx].Name = realPath
     }
+    if r.PostRender != nil {
+        r.PostRender.pathUpdate(r.IncludePath)
+    }
 }
 
 // Check file existence
@@ -45,23 +64,26 @@ func fileIsExists(file *string) error {
 
 // CheckScripts checks if defined script files exists.
 func (r *Release) checkScripts() error {
-    // check before scripts
-    for _, s := range r.BeforeScripts {
-        if err := fileIsExists(s); err != nil {
+    for _, s := range r.scripts() {
+        if err := s.check(); err != nil {
             return err
         }
     }
 
-    // check after scripts
-    for _, s := range r.AfterScripts {
-        if err := fileIsExists(s); err != nil {
+    for _, vf := range r.ValueFiles {
+        if err := fileIsExists(&vf.Name); err != nil {
             return err
         }
     }
 
-    for _, vf := range r.ValueFiles {
-        if err := fileIsExists(&vf.Name); err != nil {
-            return err
+    if r.PostRender != nil {
+        for _, p := range r.PostRender.Patches {
+            if p.Path == "" {
+                continue
+            }
+            if err := fileIsExists(&p.Path); err != nil {
+                return err
+            }
         }
     }
 
@@ -81,15 +103,32 @@ func (r *Release) setDefaults() {
         r.Values = []*Value{}
     }
 
-    if r.Atomic == nil {
-        f := false
-        r.Atomic = &f
+    for _, flag := range r.flags() {
+        if *flag == nil {
+            f := false
+            *flag = &f
+        }
+    }
+    if r.ExtraArgs == nil {
+        r.ExtraArgs = []string{}
     }
     if r.AfterScripts == nil {
-        r.AfterScripts = []*string{}
+        r.AfterScripts = []*Script{}
     }
     if r.BeforeScripts == nil {
-        r.BeforeScripts = []*string{}
+        r.BeforeScripts = []*Script{}
+    }
+    if r.OnFailureScripts == nil {
+        r.OnFailureScripts = []*Script{}
+    }
+    if r.AfterUninstallScripts == nil {
+        r.AfterUninstallScripts = []*Script{}
+    }
+    if r.BeforeUninstallScripts == nil {
+        r.BeforeUninstallScripts = []*Script{}
+    }
+    if r.Needs == nil {
+        r.Needs = []string{}
     }
     if r.Namespace == "" {
         r.Namespace = r.Name
@@ -98,3 +137,127 @@ func (r *Release) setDefaults() {
         r.Repository = &Repository{}
     }
 }
+
+// copy returns deep copy of release, so target params can be merged
+// without modification of the original release.
+func (r *Release) copy() *Release {
+    c := *r
+
+    c.BeforeScripts = copyScripts(r.BeforeScripts)
+    c.AfterScripts = copyScripts(r.AfterScripts)
+    c.OnFailureScripts = copyScripts(r.OnFailureScripts)
+    c.BeforeUninstallScripts = copyScripts(r.BeforeUninstallScripts)
+    c.AfterUninstallScripts = copyScripts(r.AfterUninstallScripts)
+
+    if r.Needs != nil {
+        c.Needs = append([]string{}, r.Needs...)
+    }
+    for _, flag := range c.flags() {
+        if *flag != nil {
+            value := **flag
+            *flag = &value
+        }
+    }
+    if r.MaxHistory != nil {
+        maxHistory := *r.MaxHistory
+        c.MaxHistory = &maxHistory
+    }
+    if r.ExtraArgs != nil {
+        c.ExtraArgs = append([]string{}, r.ExtraArgs...)
+    }
+    if r.PostRender != nil {
+        c.PostRender = r.PostRender.copy()
+    }
+    if r.Repository != nil {
+        repo := *r.Repository
+        c.Repository = &repo
+    }
+    if r.Values != nil {
+        c.Values = make([]*Value, len(r.Values))
+        for idx, v := range r.Values {
+            value := *v
+            c.Values[idx] = &value
+        }
+    }
+    if r.ValueFiles != nil {
+        c.ValueFiles = make([]*ValueFile, len(r.ValueFiles))
+        for idx, vf := range r.ValueFiles {
+            valueFile := *vf
+            if vf.Decrypt != nil {
+                decrypt := *vf.Decrypt
+                valueFile.Decrypt = &decrypt
+            }
+            c.ValueFiles[idx] = &valueFile
+        }
+    }
+
+    return &c
+}
+
+// optionsTransformer merges release options defined as pointers, so false
+// and zero values of target params override values of the release.
+type optionsTransformer struct{}
+
+// Transformer returns merge function for boolean and integer options.
+func (optionsTransformer) Transformer(t reflect.Type) func(dst, src reflect.Value) error {
+    switch t {
+    case reflect.TypeOf((*bool)(nil)), reflect.TypeOf((*int)(nil)):
+        return func(dst, src reflect.Value) error {
+            if !src.IsNil() && dst.CanSet() {
+                dst.Set(src)
+            }
+            return nil
+        }
+    }
+    return nil
+}
+
+// flags returns pointers to boolean helm options of release.
+func (r *Release) flags() []**bool {
+    return []**bool{
+        &r.Atomic, &r.Wait, &r.WaitForJobs, &r.Force, &r.CleanupOnFail,
+        &r.SkipCRDs, &r.ResetValues, &r.ReuseValues, &r.DisableHooks,
+    }
+}
+
+// checkOptions checks helm options of release.
+func (r *Release) checkOptions() error {
+    if r.Timeout != "" {
+        if _, err := time.ParseDuration(r.Timeout); err != nil {
+            return fmt.Errorf("release %s has invalid timeout, %v", r.Name, err)
+        }
+    }
+    if r.ReleaseTimeout != "" {
+        if _, err := time.ParseDuration(r.ReleaseTimeout); err != nil {
+            return fmt.Errorf("release %s has invalid releaseTimeout, %v", r.Name, err)
+        }
+    }
+    if r.ResetValues != nil && *r.ResetValues && r.ReuseValues != nil && *r.ReuseValues {
+        return fmt.Errorf("release %s: resetValues and reuseValues cannot be used together", r.Name)
+    }
+    if r.MaxHistory != nil && *r.MaxHistory < 0 {
+        return fmt.Errorf("release %s: maxHistory cannot be negative", r.Name)
+    }
+    if r.PostRender != nil {
+        if err := r.PostRender.check(); err != nil {
+            return fmt.Errorf("release %s: %v", r.Name, err)
+        }
+    }
+    return nil
+}
+
+// GetReleaseTimeout returns maximum time of release installing with scripts,
+// zero timeout is not set.
+func (r *Release) GetReleaseTimeout() time.Duration {
+    d, _ := time.ParseDuration(r.ReleaseTimeout)
+    return d
+}
+
+// scripts returns all scripts of release.
+func (r *Release) scripts() []*Script {
+    scripts := []*Script{}
+    for _, list := range [][]*Script{r.BeforeScripts, r.AfterScripts, r.OnFailureScripts, r.BeforeUninstallScripts, r.AfterUninstallScripts} {
+        scripts = append(scripts, list...)
+    }
+    return scripts
+}
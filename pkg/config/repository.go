@@ -1,9 +1,21 @@
 package config
 
 // Repository represents helm repository object.
+// Repository with oci:// URL is an OCI registry, helmctl logs in to it
+// and charts are referenced as oci://<registry>/<path>/<chart>.
 type Repository struct {
-    Name     string
-    URL      string
-    User     string
-    Password string
+    Name string `json:"name,omitempty" yaml:"name,omitempty"`
+    URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+    User string `json:"user,omitempty" yaml:"user,omitempty"`
+    // Password is never printed.
+    Password string `json:"-" yaml:"-"`
+    // CAFile verifies certificates of HTTPS-enabled servers.
+    CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+    // CertFile and KeyFile identify HTTPS client.
+    CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+    KeyFile  string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+    // InsecureSkipTLSVerify skips certificate verification of server.
+    InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty" yaml:"insecureSkipTLSVerify,omitempty"`
+    // PassCredentials passes credentials to all domains.
+    PassCredentials bool `json:"passCredentials,omitempty" yaml:"passCredentials,omitempty"`
 }
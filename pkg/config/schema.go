@@ -100,7 +100,12 @@ const (
                     "name": {"type": "string"},
                     "url": {"type": "string"},
                     "user": {"type": "string"},
-                    "password": {"type": "string"}
+                    "password": {"type": "string"},
+                    "caFile": {"type": "string"},
+                    "certFile": {"type": "string"},
+                    "keyFile": {"type": "string"},
+                    "insecureSkipTLSVerify": {"type": "boolean"},
+                    "passCredentials": {"type": "boolean"}
                 },
             "additionalProperties": false,
             "required": ["name", "url"]
@@ -121,11 +126,80 @@ const (
             "required": ["name", "value"]
         },
 
+        "script": {"oneOf": [
+            {"type": "string"},
+            {
+                "type": "object",
+                "properties": {
+                    "path": {"type": "string"},
+                    "run": {"type": "string"},
+                    "args": {"type": "array", "items": {"type": "string"}},
+                    "dir": {"type": "string"},
+                    "timeout": {"type": "string"}
+                },
+                "additionalProperties": false,
+                "oneOf": [{"required": ["path"]}, {"required": ["run"]}]
+            }
+        ]},
+
+        "hooks": {
+            "type": "object",
+            "properties": {
+                "beforeAll": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterAll": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "onFailure": {"type": "array", "items": {"$ref": "#/definitions/script"}}
+            },
+            "additionalProperties": false
+        },
+
+        "specHooks": {
+            "type": "object",
+            "properties": {
+                "beforeAll": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterAll": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "onFailure": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "environments": {"type": "object", "additionalProperties": {"$ref": "#/definitions/hooks"}},
+                "projects": {"type": "object", "additionalProperties": {"$ref": "#/definitions/hooks"}}
+            },
+            "additionalProperties": false
+        },
+
+        "patch": {
+            "type": "object",
+            "properties": {
+                "type": {"type": "string", "enum": ["strategicMerge", "json6902"]},
+                "patch": {"type": "string"},
+                "path": {"type": "string"},
+                "target": {
+                    "type": "object",
+                    "properties": {
+                        "kind": {"type": "string"},
+                        "name": {"type": "string"},
+                        "namespace": {"type": "string"}
+                    },
+                    "additionalProperties": false
+                }
+            },
+            "additionalProperties": false,
+            "oneOf": [{"required": ["patch"]}, {"required": ["path"]}]
+        },
+
+        "postRender": {
+            "type": "object",
+            "properties": {
+                "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+                "annotations": {"type": "object", "additionalProperties": {"type": "string"}},
+                "patches": {"type": "array", "items": {"$ref": "#/definitions/patch"}}
+            },
+            "additionalProperties": false
+        },
+
         "valueFile": {
             "type": "object",
             "properties": {
                 "name": {"type": "string"},
-                "decrypt": {"type": "boolean"}
+                "decrypt": {"type": "boolean"},
+                "format": {"type": "string", "enum": ["yaml", "json", "dotenv", "ini", "binary"]}
             },
             "additionalProperties": false,
             "required": ["name"]
@@ -138,12 +212,30 @@ const (
                 "chart": {"type": "string"},
                 "version": {"type": "string"},
                 "namespace": {"type": "string"},
-                "beforeScripts": {"type": "array", "items": {"type": "string"}},
-                "afterScripts": {"type": "array", "items": {"type": "string"}},
+                "beforeScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "onFailureScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "beforeUninstallScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterUninstallScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
                 "atomic": {"type": "boolean"},
+                "wait": {"type": "boolean"},
+                "waitForJobs": {"type": "boolean"},
+                "timeout": {"type": "string"},
+                "releaseTimeout": {"type": "string"},
+                "force": {"type": "boolean"},
+                "cleanupOnFail": {"type": "boolean"},
+                "skipCRDs": {"type": "boolean"},
+                "maxHistory": {"type": "integer", "minimum": 0},
+                "description": {"type": "string"},
+                "resetValues": {"type": "boolean"},
+                "reuseValues": {"type": "boolean"},
+                "disableHooks": {"type": "boolean"},
+                "extraArgs": {"type": "array", "items": {"type": "string"}},
+                "postRender": {"$ref": "#/definitions/postRender"},
                 "repository": { "$ref": "#/definitions/repository" },
                 "values": {"type": "array", "items": {"$ref": "#/definitions/value"}},
                 "valueFiles": {"type": "array", "items": {"$ref": "#/definitions/valueFile"}},
+                "needs": {"type": "array", "items": {"type": "string"}},
                 "IncludePath": {"type": "string"}
             },
             "additionalProperties": false,
@@ -158,12 +250,30 @@ const (
                 "version": {"type": "string"},
                 "include": {"type": "string"},
                 "namespace": {"type": "string"},
-                "beforeScripts": {"type": "array", "items": {"type": "string"}},
-                "afterScripts": {"type": "array", "items": {"type": "string"}},
+                "beforeScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "onFailureScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "beforeUninstallScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
+                "afterUninstallScripts": {"type": "array", "items": {"$ref": "#/definitions/script"}},
                 "atomic": {"type": "boolean"},
+                "wait": {"type": "boolean"},
+                "waitForJobs": {"type": "boolean"},
+                "timeout": {"type": "string"},
+                "releaseTimeout": {"type": "string"},
+                "force": {"type": "boolean"},
+                "cleanupOnFail": {"type": "boolean"},
+                "skipCRDs": {"type": "boolean"},
+                "maxHistory": {"type": "integer", "minimum": 0},
+                "description": {"type": "string"},
+                "resetValues": {"type": "boolean"},
+                "reuseValues": {"type": "boolean"},
+                "disableHooks": {"type": "boolean"},
+                "extraArgs": {"type": "array", "items": {"type": "string"}},
+                "postRender": {"$ref": "#/definitions/postRender"},
                 "repository": { "$ref": "#/definitions/repository" },
                 "values": {"type": "array", "items": {"$ref": "#/definitions/value"}},
                 "valueFiles": {"type": "array", "items": {"$ref": "#/definitions/valueFile"}},
+                "needs": {"type": "array", "items": {"type": "string"}},
                 "IncludePath": {"type": "string"}
             },
             "additionalProperties": false,
@@ -231,7 +341,9 @@ const (
                         "projects": {"$ref": "#/definitions/customMap"}
                     },
                     "additionalProperties": false
-                }
+                },
+
+                "hooks": {"$ref": "#/definitions/specHooks"}
             },
             "required": [
                 "releases",
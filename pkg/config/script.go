@@ -0,0 +1,108 @@
+package config
+
+import (
+    "fmt"
+    "path/filepath"
+    "reflect"
+    "time"
+
+    "github.com/mitchellh/mapstructure"
+)
+
+// Script represents script executed before or after release installing
+// or uninstalling. Script is defined as a path of script file or as an
+// object with path or inline run command.
+type Script struct {
+    // Path of executable script file.
+    Path string `json:"path,omitempty" yaml:"path,omitempty"`
+    // Run is inline command executed with sh -c.
+    Run string `json:"run,omitempty" yaml:"run,omitempty"`
+    // Args are passed to script, for inline command they are $1, $2, ...
+    Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+    // Dir is working directory of script, current directory is used if empty.
+    Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+    // Timeout of script, e.g. 5m, not limited if empty.
+    Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// String returns script path or inline command.
+func (s *Script) String() string {
+    if s.Path != "" {
+        return s.Path
+    }
+    return "inline script"
+}
+
+// GetTimeout returns script timeout, zero timeout is not limited.
+func (s *Script) GetTimeout() time.Duration {
+    d, _ := time.ParseDuration(s.Timeout)
+    return d
+}
+
+// pathUpdate prepends path of config file to relative script path and dir.
+func (s *Script) pathUpdate(includePath string) {
+    if s.Path != "" && !filepath.IsAbs(s.Path) {
+        s.Path = filepath.Join(ConfigFilePath, filepath.Dir(includePath), s.Path)
+    }
+    if s.Dir != "" && !filepath.IsAbs(s.Dir) {
+        s.Dir = filepath.Join(ConfigFilePath, filepath.Dir(includePath), s.Dir)
+    }
+}
+
+// check checks script definition and existence of script file.
+func (s *Script) check() error {
+    if (s.Path == "") == (s.Run == "") {
+        return fmt.Errorf("script must have either path or run")
+    }
+    if s.Timeout != "" {
+        if _, err := time.ParseDuration(s.Timeout); err != nil {
+            return fmt.Errorf("script %s has invalid timeout, %v", s, err)
+        }
+    }
+    if s.Path != "" {
+        return fileIsExists(&s.Path)
+    }
+    return nil
+}
+
+// copyScripts returns deep copy of scripts slice.
+func copyScripts(in []*Script) []*Script {
+    if in == nil {
+        return nil
+    }
+    out := make([]*Script, len(in))
+    for idx, s := range in {
+        script := *s
+        if s.Args != nil {
+            script.Args = append([]string{}, s.Args...)
+        }
+        out[idx] = &script
+    }
+    return out
+}
+
+// scriptHook decodes script defined as a string into script with path.
+func scriptHook(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+    if f.Kind() != reflect.String {
+        return data, nil
+    }
+    switch t {
+    case reflect.TypeOf(Script{}):
+        return Script{Path: data.(string)}, nil
+    case reflect.TypeOf(&Script{}):
+        return &Script{Path: data.(string)}, nil
+    }
+    return data, nil
+}
+
+// decode decodes map into struct, scripts could be defined as strings.
+func decode(input interface{}, output interface{}) error {
+    decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+        DecodeHook: scriptHook,
+        Result:     output,
+    })
+    if err != nil {
+        return err
+    }
+    return decoder.Decode(input)
+}
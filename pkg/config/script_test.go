@@ -0,0 +1,35 @@
+package config
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestScriptDecode(t *testing.T) {
+    input := map[string]interface{}{
+        "name": "app",
+        "beforeScripts": []interface{}{
+            "scripts/before.sh",
+            map[string]interface{}{"run": "echo $1", "args": []interface{}{"one"}, "timeout": "1m"},
+        },
+    }
+
+    r := &Release{}
+    if err := decode(input, r); err != nil {
+        t.Fatal(err)
+    }
+
+    want := []*Script{
+        {Path: "scripts/before.sh"},
+        {Run: "echo $1", Args: []string{"one"}, Timeout: "1m"},
+    }
+    if !reflect.DeepEqual(r.BeforeScripts, want) {
+        t.Errorf("unexpected scripts %+v %+v", r.BeforeScripts[0], r.BeforeScripts[1])
+    }
+
+    for _, s := range []*Script{{}, {Path: "a", Run: "b"}, {Run: "b", Timeout: "soon"}} {
+        if err := s.check(); err == nil {
+            t.Errorf("expected error for script %+v", s)
+        }
+    }
+}
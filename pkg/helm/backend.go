@@ -0,0 +1,46 @@
+package helm
+
+import (
+	"context"
+	"os"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// backend executes helm operations for ShellClient. Every method receives
+// the client to use its options and logger.
+type backend interface {
+	upgrade(ctx context.Context, sc *ShellClient, r *config.Release, in *InstallOptions) (string, error)
+	diff(ctx context.Context, sc *ShellClient, r *config.Release) (bool, string, error)
+	exists(ctx context.Context, sc *ShellClient, name, namespace string) (bool, error)
+	uninstall(ctx context.Context, sc *ShellClient, name, namespace string) (bool, string, error)
+	list(ctx context.Context, sc *ShellClient, target string, targetType config.TargetType) ([]*listedRelease, error)
+	rollback(ctx context.Context, sc *ShellClient, name, namespace string, revision int) (string, error)
+	template(ctx context.Context, sc *ShellClient, r *config.Release, dir string) error
+	lint(ctx context.Context, sc *ShellClient, r *config.Release, dir string) (string, error)
+	// repoAdd adds repository, present repository is replaced if force is set.
+	repoAdd(ctx context.Context, sc *ShellClient, repo *config.Repository, force bool) error
+	// repoList returns repositories of helm repositories file.
+	repoList(ctx context.Context, sc *ShellClient) ([]*config.Repository, error)
+	// repoUpdate updates indexes of named repositories.
+	repoUpdate(ctx context.Context, sc *ShellClient, names []string) error
+	repoRemove(ctx context.Context, sc *ShellClient, repo *config.Repository) error
+	// args returns helm arguments of release upgrade or diff,
+	// nil if helm binary is not used.
+	args(sc *ShellClient, r *config.Release, in *InstallOptions) []string
+}
+
+// listedRelease represents installed helm release.
+type listedRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  string `json:"revision"`
+	Status    string `json:"status"`
+	Chart     string `json:"chart"`
+}
+
+// isLocalChart checks if chart is a local directory or archive.
+func isLocalChart(chart string) bool {
+	_, err := os.Stat(chart)
+	return err == nil
+}
@@ -0,0 +1,107 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// DiffStatus is a state of release compared with installed one.
+type DiffStatus string
+
+// Define diff statuses
+const (
+	DiffUnchanged DiffStatus = "unchanged"
+	DiffChanged   DiffStatus = "changed"
+	DiffNew       DiffStatus = "new"
+)
+
+// DiffResult contains helm diff result of a release.
+type DiffResult struct {
+	Release   string
+	Namespace string
+	Status    DiffStatus
+	Output    string
+}
+
+// Diff compares release or releases from config with installed ones.
+// Scripts are not executed and namespaces are not created.
+func (sc *ShellClient) Diff(ctx context.Context, in *InstallOptions) ([]*DiffResult, error) {
+	defer sc.decryptedRemove()
+	defer sc.chartsRemove()
+
+	if err := sc.targetReposSync(ctx, in.Release, in.Target, in.TargetType); err != nil {
+		sc.l.Errorf("Failed to add helm repositories, %v", err)
+		return nil, err
+	}
+
+	// lock is not updated by diff
+	lock, err := sc.lockLoad()
+	if err != nil {
+		return nil, err
+	}
+	in.lock = lock
+
+	var releases []*config.Release
+	if in.Release == "all" {
+		releases, err = sc.cfg.TargetReleases(in.Target, in.TargetType)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		r, err := sc.cfg.TargetRelease(in.Release, in.Target, in.TargetType)
+		if err != nil {
+			return nil, err
+		}
+		releases = []*config.Release{r}
+	}
+
+	results := []*DiffResult{}
+	for _, r := range releases {
+		result, err := sc.releaseDiff(ctx, r, in)
+		if err != nil {
+			return results, fmt.Errorf("release %s: %v", r.Name, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// releaseDiff runs helm diff for release.
+func (sc *ShellClient) releaseDiff(ctx context.Context, r *config.Release, in *InstallOptions) (*DiffResult, error) {
+	sc.l.Infof("Diff helm release %s", r.Name)
+
+	// pin locked chart version
+	if err := sc.lockCheck(in.lock, r, in.Target, in.TargetType); err != nil {
+		return nil, err
+	}
+
+	// decrypt sops
+	if err := sc.sopsDecrypt(r.ValueFiles); err != nil {
+		return nil, err
+	}
+
+	installed, err := sc.backend.exists(ctx, sc, r.Name, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiffResult{Release: r.Name, Namespace: r.Namespace, Status: DiffUnchanged}
+
+	changed, out, err := sc.backend.diff(ctx, sc, r)
+	if err != nil {
+		return nil, err
+	}
+	result.Output = out
+
+	switch {
+	case !installed:
+		result.Status = DiffNew
+	case changed:
+		result.Status = DiffChanged
+	}
+
+	return result, nil
+}
@@ -2,26 +2,44 @@ package helm
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/url"
-	"os"
-	"os/exec"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sprokhorov/helmctl/pkg/config"
 	helmctlKubernetes "github.com/sprokhorov/helmctl/pkg/kubernetes"
-	"go.mozilla.org/sops/v3/decrypt"
+	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/client-go/kubernetes"
 )
 
 // Helm represents helm.
 type Helm interface {
-	Install(in *InstallOptions) error
+	Install(ctx context.Context, in *InstallOptions) error
+	Uninstall(ctx context.Context, in *UninstallOptions) error
+	Prune(ctx context.Context, in *PruneOptions) error
+	Rollback(ctx context.Context, in *RollbackOptions) error
+	Diff(ctx context.Context, in *InstallOptions) ([]*DiffResult, error)
+	Template(ctx context.Context, in *TemplateOptions) error
+	Lint(ctx context.Context, in *LintOptions) ([]*LintResult, error)
+	Lock(ctx context.Context, in *LockOptions) error
+	ReposSync(ctx context.Context, in *ReposOptions) error
+	ReposUpdate(ctx context.Context, in *ReposOptions) error
+	ReposRemove(ctx context.Context, in *ReposOptions) error
 }
 
+// Define helm release labels used to mark releases installed by helmctl.
+const (
+	OwnerLabel      = "helmctl/owner"
+	OwnerValue      = "helmctl"
+	TargetLabel     = "helmctl/target"
+	TargetTypeLabel = "helmctl/target-type"
+)
+
 // NewShellClient return new ShellClient.
 func NewShellClient(cfg config.Config, opts *ShellClientOptions) (Helm, error) {
 	if cfg == nil {
@@ -36,7 +54,22 @@ func NewShellClient(cfg config.Config, opts *ShellClientOptions) (Helm, error) {
 		opts.Logger = logrus.New()
 	}
 
-	return &ShellClient{cfg: cfg, opts: opts, l: opts.Logger}, nil
+	runner := opts.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+
+	sc := &ShellClient{
+		cfg:       cfg,
+		opts:      opts,
+		l:         opts.Logger,
+		backend:   &shellBackend{},
+		runner:    runner,
+		sharedMu:  &sync.Mutex{},
+		decrypted: &decryptedFiles{},
+	}
+	sc.charts = &chartResolver{sc: sc, indexes: map[string]*repo.IndexFile{}}
+	return sc, nil
 }
 
 // ShellClient implements Helm as a shell call to helm binary client.
@@ -44,6 +77,20 @@ type ShellClient struct {
 	cfg  config.Config
 	l    *logrus.Logger
 	opts *ShellClientOptions
+
+	// backend executes helm operations.
+	backend backend
+	// runner executes helm binary and scripts.
+	runner Runner
+	// sharedMu serializes steps which modify helm repositories file.
+	sharedMu *sync.Mutex
+	// reposUpdated is true after indexes of repositories were updated, they
+	// are updated once per run. It is guarded by sharedMu.
+	reposUpdated bool
+	// decrypted contains decrypted copies of value files.
+	decrypted *decryptedFiles
+	// charts resolves chart versions for lock file.
+	charts *chartResolver
 }
 
 // ShellClientOptions contains options for ShellClient.
@@ -58,14 +105,30 @@ type ShellClientOptions struct {
 	Logger *logrus.Logger
 	// If true repositories adding will be skipped.
 	SkipRepositories bool
+	// If true indexes of present repositories are not updated by install,
+	// diff, template and lint.
+	SkipReposUpdate bool
+	// If true present repositories are added again.
+	ForceUpdate bool
 	// If true scripts running will be skipped.
 	SkipScripts bool
-	// Path to sops config file.
+	// Path to sops config file, it is used only to warn about value files
+	// without creation rule and does not affect decryption.
 	SopsConfig string
 	// Allow scripts running in dry run mode.
 	WithScripts bool
 	// Helm binary path
 	HelmPath string
+	// Path to file with recorded runs, runs are not recorded if empty.
+	HistoryFile string
+	// Path to lock file, chart versions are not locked if empty.
+	LockFile string
+	// If true lock file is updated with resolved chart versions.
+	UpdateLock bool
+	// Runner executes external commands, ExecRunner is used if nil.
+	Runner Runner
+	// Path of helmctl executable used as helm post-renderer, current executable is used if empty.
+	Executable string
 }
 
 // NewShellClientOptions creates new ShellClientOptions object.
@@ -80,10 +143,27 @@ func NewShellClientOptions(logger *logrus.Logger) *ShellClientOptions {
 
 // InstallOptions contains arguments for Install method.
 type InstallOptions struct {
-	Release          string
-	Target           string
-	TargetType       config.TargetType
-	KubernetesClient *kubernetes.Clientset
+	Release    string
+	Target     string
+	TargetType config.TargetType
+	Prune      bool
+	// Number of releases installed at the same time by all.
+	Parallel         int
+	KubernetesClient kubernetes.Interface
+	// Install releases which do not need failed ones after a failure.
+	KeepGoing bool
+	// Report is filled with results of releases if set.
+	Report *Report
+	// Stop is closed to stop installing of all, running releases are finished.
+	Stop <-chan struct{}
+	// Maximum time of a release installing with scripts and helm, not limited
+	// if zero. releaseTimeout of release config overrides it.
+	ReleaseTimeout time.Duration
+
+	// run records releases touched by install.
+	run *Run
+	// lock contains locked chart versions, nil if there is no lock file.
+	lock *Lock
 }
 
 // NewInstallOptions creates new InstallOptions object.
@@ -91,29 +171,90 @@ func NewInstallOptions() *InstallOptions {
 	return &InstallOptions{TargetType: config.TargetEnvironments}
 }
 
+// UninstallOptions contains arguments for Uninstall method.
+type UninstallOptions struct {
+	Release          string
+	Target           string
+	TargetType       config.TargetType
+	DeleteNamespace  bool
+	KubernetesClient kubernetes.Interface
+}
+
+// NewUninstallOptions creates new UninstallOptions object.
+func NewUninstallOptions() *UninstallOptions {
+	return &UninstallOptions{TargetType: config.TargetEnvironments}
+}
+
+// PruneOptions contains arguments for Prune method.
+type PruneOptions struct {
+	Target     string
+	TargetType config.TargetType
+}
+
+// NewPruneOptions creates new PruneOptions object.
+func NewPruneOptions() *PruneOptions {
+	return &PruneOptions{TargetType: config.TargetEnvironments}
+}
+
 // Install installs release or releases from config.
-func (sc *ShellClient) Install(in *InstallOptions) error {
-	// add global repos
-	if err := sc.reposAdd(); err != nil {
+func (sc *ShellClient) Install(ctx context.Context, in *InstallOptions) error {
+	defer sc.decryptedRemove()
+	defer sc.chartsRemove()
+
+	if err := sc.targetReposSync(ctx, in.Release, in.Target, in.TargetType); err != nil {
 		sc.l.Errorf("Failed to add helm repositories, %v", err)
 		return err
 	}
 
-	var err error = nil
-	in.KubernetesClient, err = helmctlKubernetes.GetKubernetesClient("")
+	if in.KubernetesClient == nil {
+		client, err := helmctlKubernetes.GetKubernetesClient("")
+		if err != nil {
+			sc.l.Errorf("Cannot create Kubernetes client, %v", err)
+			return err
+		}
+		in.KubernetesClient = client
+	}
+
+	if in.Report != nil {
+		in.Report.Target = in.Target
+		in.Report.TargetType = in.TargetType
+		in.Report.Diff = sc.opts.Diff
+		in.Report.DryRun = sc.opts.DryRun
+		defer func() {
+			in.Report.Duration = time.Since(in.Report.Started).Seconds()
+		}()
+	}
+
+	if sc.opts.HistoryFile != "" && !sc.opts.Diff && !sc.opts.DryRun {
+		in.run = &Run{Target: in.Target, TargetType: in.TargetType, Time: time.Now()}
+		defer sc.historySave(in.run)
+	}
+
+	lock, err := sc.lockLoad()
 	if err != nil {
-		sc.l.Errorf("Cannot create Kubernetes client, %v", err)
 		return err
 	}
+	in.lock = lock
+	if lock != nil && !sc.opts.Diff && !sc.opts.DryRun {
+		defer sc.lockSave(lock)
+	}
 
 	// install
-	if in.Release == "all" {
-		return sc.installAll(in)
+	if in.Release != "all" {
+		return sc.installOne(ctx, in)
+	}
+
+	if err := sc.installAllHooked(ctx, in); err != nil {
+		return err
+	}
+
+	if in.Prune && !sc.opts.Diff {
+		return sc.Prune(ctx, &PruneOptions{Target: in.Target, TargetType: in.TargetType})
 	}
-	return sc.installOne(in)
+	return nil
 }
 
-func (sc *ShellClient) installOne(in *InstallOptions) error {
+func (sc *ShellClient) installOne(ctx context.Context, in *InstallOptions) error {
 	r, err := sc.cfg.TargetRelease(in.Release, in.Target, in.TargetType)
 	if err != nil {
 		return err
@@ -121,56 +262,179 @@ func (sc *ShellClient) installOne(in *InstallOptions) error {
 
 	if sc.opts.Diff {
 		var output bytes.Buffer
-		err = sc.releaseInstall(r, in, &output)
+		err = sc.releaseInstall(ctx, r, in, &output)
+		in.Report.release(r, in).finish(stateOf(err), err)
 		if err != nil {
 			return err
 		}
 		sc.l.Infof("Helm diff: %s", output.String())
 		return nil
 	} else {
-		return sc.releaseInstall(r, in, nil)
+		err = sc.releaseInstall(ctx, r, in, nil)
+		in.Report.release(r, in).finish(stateOf(err), err)
+		return err
+	}
+}
+
+// installAllHooked installs all releases of target, target hooks are executed
+// before and after installing or if installing failed.
+func (sc *ShellClient) installAllHooked(ctx context.Context, in *InstallOptions) error {
+	hooks, err := sc.cfg.TargetHooks(in.Target, in.TargetType)
+	if err != nil {
+		return err
 	}
+
+	err = sc.hooksExecute(ctx, in.Target, in.TargetType, hooks.BeforeAll, nil)
+	if err == nil {
+		err = sc.installAll(ctx, in)
+	}
+	if err == nil {
+		err = sc.hooksExecute(ctx, in.Target, in.TargetType, hooks.AfterAll, nil)
+	}
+	if err != nil {
+		// ctx could be cancelled already, failure hooks are limited by their timeouts only
+		if ferr := sc.hooksExecute(context.Background(), in.Target, in.TargetType, hooks.OnFailure, err); ferr != nil {
+			sc.l.Errorf("Failure hooks of %s %s failed, %v", in.TargetType, in.Target, ferr)
+		}
+	}
+	return err
 }
 
-func (sc *ShellClient) installAll(in *InstallOptions) error {
+func (sc *ShellClient) installAll(ctx context.Context, in *InstallOptions) error {
 	releases, err := sc.cfg.TargetReleases(in.Target, in.TargetType)
 	if err != nil {
 		return err
 	}
 
-	var output bytes.Buffer
+	outputs := make(map[string]*bytes.Buffer, len(releases))
 	for _, r := range releases {
+		outputs[r.Name] = &bytes.Buffer{}
+		// keep report in order of releases
+		in.Report.release(r, in)
+	}
+
+	results := schedule(in.Stop, releases, in.Parallel, in.KeepGoing, func(r *config.Release) error {
+		var output *bytes.Buffer
 		if sc.opts.Diff {
-			if err := sc.releaseInstall(r, in, &output); err != nil {
-				return err
-			}
-		} else {
-			if err := sc.releaseInstall(r, in, nil); err != nil {
-				return err
-			}
+			output = outputs[r.Name]
 		}
-	}
+
+		if in.Parallel <= 1 {
+			return sc.releaseInstall(ctx, r, in, output)
+		}
+
+		// keep logs of release together
+		var logs bytes.Buffer
+		err := sc.withLogger(bufferedLogger(sc.l, &logs)).releaseInstall(ctx, r, in, output)
+		sc.l.Out.Write(logs.Bytes())
+		return err
+	})
 
 	if sc.opts.Diff {
+		var output bytes.Buffer
+		for _, r := range releases {
+			output.Write(outputs[r.Name].Bytes())
+		}
 		sc.l.Infof("Helm diff:\n%s", output.String())
 	}
 
+	if in.KeepGoing {
+		sc.installSummary(results)
+	}
+
+	failures := []string{}
+	skipped := 0
+	for _, res := range results {
+		in.Report.release(res.release, in).finish(res.state, res.err)
+		switch res.state {
+		case stateFailed:
+			failures = append(failures, fmt.Sprintf("%s: %v", res.release.Name, res.err))
+		case stateSkipped:
+			sc.l.Warnf("Helm release %s was skipped", res.release.Name)
+			skipped++
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed releases:\n%s", strings.Join(failures, "\n"))
+	}
+	if skipped > 0 && stopped(in.Stop) {
+		return fmt.Errorf("installing was stopped, %d releases were skipped", skipped)
+	}
+
 	return nil
 }
 
-// releaseInstall installs helm release.
-func (sc *ShellClient) releaseInstall(r *config.Release, in *InstallOptions, outputBuffer *bytes.Buffer) error {
+// installSummary prints table of release results.
+func (sc *ShellClient) installSummary(results []*releaseResult) {
+	w := tabwriter.NewWriter(sc.l.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RELEASE\tNAMESPACE\tRESULT\tERROR")
+	for _, res := range results {
+		reason := "-"
+		if res.err != nil {
+			reason = strings.ReplaceAll(res.err.Error(), "\n", " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.release.Name, res.release.Namespace, res.state, reason)
+	}
+	w.Flush()
+}
+
+// withLogger returns copy of client which writes logs to logger.
+func (sc *ShellClient) withLogger(l *logrus.Logger) *ShellClient {
+	c := *sc
+	c.l = l
+	return &c
+}
+
+// bufferedLogger returns logger with settings of l which writes to buffer.
+func bufferedLogger(l *logrus.Logger, buf *bytes.Buffer) *logrus.Logger {
+	bl := logrus.New()
+	bl.Out = buf
+	bl.Formatter = l.Formatter
+	bl.Level = l.Level
+	return bl
+}
+
+// releaseInstall installs helm release, failure scripts of release are
+// executed if installing failed.
+func (sc *ShellClient) releaseInstall(ctx context.Context, r *config.Release, in *InstallOptions, outputBuffer *bytes.Buffer) error {
+	err := sc.releaseApply(ctx, r, in, outputBuffer)
+	if err != nil && len(r.OnFailureScripts) > 0 {
+		// ctx could be cancelled or timed out with release, so failure
+		// scripts are limited by their timeouts only
+		if ferr := sc.failureScriptsExecute(context.Background(), r, in.Target, in.TargetType, r.OnFailureScripts, err); ferr != nil {
+			sc.l.Errorf("Failure scripts of release %s failed, %v", r.Name, ferr)
+		}
+	}
+	return err
+}
+
+// releaseApply runs release scripts and helm to install release.
+func (sc *ShellClient) releaseApply(ctx context.Context, r *config.Release, in *InstallOptions, outputBuffer *bytes.Buffer) error {
 	sc.l.Infof("Install helm release %s", r.Name)
-	if err := sc.scriptsExecute(r.BeforeScripts); err != nil {
+	// release timeout of config overrides the one of command
+	timeout := in.ReleaseTimeout
+	if d := r.GetReleaseTimeout(); d > 0 {
+		timeout = d
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	rr := in.Report.release(r, in)
+
+	// pin locked chart version
+	if err := sc.lockCheck(in.lock, r, in.Target, in.TargetType); err != nil {
 		return err
 	}
 
-	// add repo
-	if *r.Repository != (config.Repository{}) {
-		if err := sc.repoAdd(r.Repository); err != nil {
-			return err
-		}
+	started := time.Now()
+	err := sc.scriptsExecute(ctx, r, in.Target, in.TargetType, r.BeforeScripts)
+	rr.BeforeScripts = time.Since(started).Seconds()
+	if err != nil {
+		return err
 	}
+
 	// decrypt sops
 	if err := sc.sopsDecrypt(r.ValueFiles); err != nil {
 		return err
@@ -178,32 +442,47 @@ func (sc *ShellClient) releaseInstall(r *config.Release, in *InstallOptions, out
 
 	// check existence of namespace and create it
 	if err := helmctlKubernetes.CheckNamespace(
+		ctx,
 		in.KubernetesClient,
 		r.Namespace,
 		sc.opts.DryRun); err != nil {
 		return err
 	}
 
-	// install
-	args := sc.buildArgs(r)
-	sc.l.Infof("Execute helm command: %s %s", sc.opts.HelmPath, strings.Join(args, " "))
-	out, err := exec.Command(sc.opts.HelmPath, args...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	if in.run != nil {
+		if err := sc.historyRecord(ctx, in.run, r, in.KubernetesClient); err != nil {
+			return err
+		}
 	}
 
-	outString := strings.ReplaceAll(string(out), "\n", "\n\t")
-	if outputBuffer != nil {
-		if outString != "" {
-			outputBuffer.WriteString(fmt.Sprintf("Release %s:\n%s\n", r.Name, outString))
-		} else {
+	// install
+	rr.HelmArgs = redactArgs(sc.backend.args(sc, r, in))
+	started = time.Now()
+	if sc.opts.Diff {
+		changed, out, err := sc.backend.diff(ctx, sc, r)
+		rr.Helm = time.Since(started).Seconds()
+		if err != nil {
+			return err
+		}
+		rr.Changed = &changed
+		if changed && outputBuffer != nil {
+			outputBuffer.WriteString(fmt.Sprintf("Release %s:\n%s\n", r.Name, strings.ReplaceAll(out, "\n", "\n\t")))
+		} else if outputBuffer != nil {
 			outputBuffer.WriteString(fmt.Sprintf("Release %s: no changes\n", r.Name))
 		}
 	} else {
-		sc.l.Info(outString)
+		out, err := sc.backend.upgrade(ctx, sc, r, in)
+		rr.Helm = time.Since(started).Seconds()
+		if err != nil {
+			return err
+		}
+		sc.l.Info(strings.ReplaceAll(out, "\n", "\n\t"))
 	}
 
-	if err := sc.scriptsExecute(r.AfterScripts); err != nil {
+	started = time.Now()
+	err = sc.scriptsExecute(ctx, r, in.Target, in.TargetType, r.AfterScripts)
+	rr.AfterScripts = time.Since(started).Seconds()
+	if err != nil {
 		return err
 	}
 	sc.l.Infof("Helm release %s was installed", r.Name)
@@ -211,159 +490,167 @@ func (sc *ShellClient) releaseInstall(r *config.Release, in *InstallOptions, out
 	return nil
 }
 
-func (sc *ShellClient) buildArgs(r *config.Release) []string {
-	args := []string{}
+// Uninstall uninstalls release or releases from config.
+func (sc *ShellClient) Uninstall(ctx context.Context, in *UninstallOptions) error {
+	if in.DeleteNamespace && in.KubernetesClient == nil {
+		client, err := helmctlKubernetes.GetKubernetesClient("")
+		if err != nil {
+			sc.l.Errorf("Cannot create Kubernetes client, %v", err)
+			return err
+		}
+		in.KubernetesClient = client
+	}
 
-	if sc.opts.Diff {
-		args = append(args, "diff", "upgrade", "--allow-unreleased", r.Name, "--namespace", r.Namespace)
-	} else {
-		args = append(args, "upgrade", "-i", r.Name, "--namespace", r.Namespace)
+	if in.Release == "all" {
+		return sc.uninstallAll(ctx, in)
 	}
+	return sc.uninstallOne(ctx, in)
+}
 
-	if r.Version != "" {
-		args = append(args, "--version", r.Version)
+func (sc *ShellClient) uninstallOne(ctx context.Context, in *UninstallOptions) error {
+	r, err := sc.cfg.TargetRelease(in.Release, in.Target, in.TargetType)
+	if err != nil {
+		return err
 	}
-	if *r.Atomic {
-		args = append(args, "--atomic")
+
+	if err := sc.releaseUninstall(ctx, r, in); err != nil {
+		return err
 	}
 
-	for _, vf := range r.ValueFiles {
-		args = append(args, "-f", vf.Name)
+	return sc.namespacesDelete(ctx, []*config.Release{r}, in)
+}
+
+func (sc *ShellClient) uninstallAll(ctx context.Context, in *UninstallOptions) error {
+	releases, err := sc.cfg.TargetReleases(in.Target, in.TargetType)
+	if err != nil {
+		return err
 	}
 
-	for _, v := range r.Values {
-		if v.Type == "string" {
-			args = append(args, "--set-string", v.GetKeyValuePair())
-			continue
+	// uninstall in reverse order of installation
+	for i := len(releases) - 1; i >= 0; i-- {
+		if err := sc.releaseUninstall(ctx, releases[i], in); err != nil {
+			return err
 		}
-		args = append(args, "--set", v.GetKeyValuePair())
 	}
 
-	if sc.opts.DryRun && !sc.opts.Diff {
-		args = append(args, "--dry-run")
+	// namespaces are deleted once all releases are uninstalled
+	return sc.namespacesDelete(ctx, releases, in)
+}
+
+// releaseUninstall uninstalls helm release.
+func (sc *ShellClient) releaseUninstall(ctx context.Context, r *config.Release, in *UninstallOptions) error {
+	sc.l.Infof("Uninstall helm release %s", r.Name)
+	if err := sc.scriptsExecute(ctx, r, in.Target, in.TargetType, r.BeforeUninstallScripts); err != nil {
+		return err
 	}
 
-	args = append(args, r.Chart)
+	if err := sc.helmUninstall(ctx, r.Name, r.Namespace); err != nil {
+		return err
+	}
 
-	return args
+	if err := sc.scriptsExecute(ctx, r, in.Target, in.TargetType, r.AfterUninstallScripts); err != nil {
+		return err
+	}
+	sc.l.Infof("Helm release %s was uninstalled", r.Name)
+
+	return nil
 }
 
-// scriptsExecute executes scripts.
-func (sc *ShellClient) scriptsExecute(scripts []*string) error {
-	for _, script := range scripts {
+// namespacesDelete deletes namespaces of uninstalled releases if it is requested.
+// Namespace is kept while other helm releases are stored in it.
+func (sc *ShellClient) namespacesDelete(ctx context.Context, releases []*config.Release, in *UninstallOptions) error {
+	if !in.DeleteNamespace {
+		return nil
+	}
 
-		switch {
-		case sc.opts.DryRun && !sc.opts.WithScripts:
-			sc.l.Infof("Skip %s script running, because of DryRun flag", *script)
-			continue
-		case sc.opts.SkipScripts:
-			sc.l.Infof("Skip %s script running, because of SkipScripts flag", *script)
-			continue
+	// releases are still stored in dry-run mode, so they are ignored
+	uninstalled := make(map[string]map[string]struct{})
+	namespaces := []string{}
+	for _, r := range releases {
+		if _, exists := uninstalled[r.Namespace]; !exists {
+			uninstalled[r.Namespace] = make(map[string]struct{})
+			namespaces = append(namespaces, r.Namespace)
 		}
+		uninstalled[r.Namespace][r.Name] = struct{}{}
+	}
 
-		sc.l.Infof("Run script %s", *script)
-		err := os.Chmod(*script, 0777)
+	for _, namespace := range namespaces {
+		names, err := helmctlKubernetes.HelmReleaseNames(ctx, in.KubernetesClient, namespace)
 		if err != nil {
-			fmt.Println(err)
+			return err
 		}
-		out, err := exec.Command("./" + *script).CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+
+		remaining := []string{}
+		for _, name := range names {
+			if _, exists := uninstalled[namespace][name]; !exists {
+				remaining = append(remaining, name)
+			}
+		}
+		if len(remaining) > 0 {
+			sc.l.Infof("Namespace %s contains helm releases %s, skip deletion", namespace, strings.Join(remaining, ", "))
+			continue
 		}
-	}
-	return nil
-}
 
-// reposAdd all helm repositories defined in config.
-func (sc *ShellClient) reposAdd() error {
-	for _, repo := range sc.cfg.Repositories() {
-		if err := sc.repoAdd(repo); err != nil {
+		if err := helmctlKubernetes.DeleteNamespace(ctx, in.KubernetesClient, namespace, sc.opts.DryRun); err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
-// repoAdd adds new helm repository.
-func (sc *ShellClient) repoAdd(repo *config.Repository) error {
-	if sc.opts.SkipRepositories {
-		sc.l.Infof("Skip %s repository adding, because of SkipRepositories flag", repo.Name)
-		return nil
-	}
-
-	sc.l.Infof("Add helm repository %s", repo.Name)
-	u, err := url.Parse(repo.URL)
+// helmUninstall uninstalls helm release, not installed release is not an error.
+func (sc *ShellClient) helmUninstall(ctx context.Context, name, namespace string) error {
+	found, out, err := sc.backend.uninstall(ctx, sc, name, namespace)
 	if err != nil {
 		return err
 	}
-
-	if u.Host == "" || u.Scheme == "" {
-		return errors.New("invalid repository url: <schema>://<host> format required")
-	}
-
-	if repo.User != "" && repo.Password != "" {
-		ui := url.UserPassword(repo.User, repo.Password)
-		u.User = ui
-	}
-
-	out, err := exec.Command(sc.opts.HelmPath, "repo", "add", repo.Name, u.String()).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	if !found {
+		sc.l.Infof("Helm release %s is not installed", name)
+		return nil
 	}
+	sc.l.Info(strings.ReplaceAll(out, "\n", "\n\t"))
 
 	return nil
 }
 
-// ReposUpdate updates helm repositories.
-func (sc *ShellClient) reposUpdate() error {
-	sc.l.Info("Update helm repositories")
-	out, err := exec.Command(sc.opts.HelmPath, "repo", "update").CombinedOutput()
+// Prune uninstalls releases installed by helmctl to the target
+// which are not listed in the target installs anymore.
+func (sc *ShellClient) Prune(ctx context.Context, in *PruneOptions) error {
+	releases, err := sc.cfg.TargetReleases(in.Target, in.TargetType)
 	if err != nil {
-		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+		return err
 	}
 
-	if strings.Contains(string(out), "Unable to get") {
-		return fmt.Errorf("Error: %s", strings.ReplaceAll(string(out), "\n", ""))
+	deployed, err := sc.backend.list(ctx, sc, in.Target, in.TargetType)
+	if err != nil {
+		return fmt.Errorf("cannot list helm releases, %v", err)
 	}
 
-	return nil
-}
+	// create index
+	idx := make(map[string]struct{}, len(releases))
+	for _, r := range releases {
+		idx[r.Namespace+"/"+r.Name] = struct{}{}
+	}
 
-// ReposRemove removes all helm repositories defined in config.
-func (sc *ShellClient) reposRemove() error {
-	for _, repo := range sc.cfg.Repositories() {
-		if err := sc.repoRemove(repo); err != nil {
+	for _, d := range deployed {
+		if _, configured := idx[d.Namespace+"/"+d.Name]; configured {
+			continue
+		}
+		sc.l.Infof("Prune helm release %s from namespace %s", d.Name, d.Namespace)
+		if err := sc.helmUninstall(ctx, d.Name, d.Namespace); err != nil {
 			return err
 		}
 	}
-	return nil
-}
-
-// RepoRemove removes helm repository.
-func (sc *ShellClient) repoRemove(repo *config.Repository) error {
-	out, err := exec.Command(sc.opts.HelmPath, "repo", "remove", repo.Name).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
-	}
 
 	return nil
 }
 
-// sopsDecrypt decrypts file encrypted with sops.
-func (sc *ShellClient) sopsDecrypt(vfs []*config.ValueFile) error {
-	for _, vf := range vfs {
-		if vf.GetDecrypt() {
-			sc.l.Infof("Decrypt helm value file %s", vf.Name)
-			b, err := decrypt.File(vf.Name, "yaml")
-			if err != nil {
-				return err
-			}
-			vf.Name = strings.ReplaceAll(vf.Name, ".yaml", ".decr.yaml")
-			if err := ioutil.WriteFile(vf.Name, b, 0644); err != nil {
-				return err
-			}
-		}
+// releaseLabels returns helm release labels which mark release as installed by helmctl.
+func releaseLabels(target string, targetType config.TargetType) map[string]string {
+	return map[string]string{
+		OwnerLabel:      OwnerValue,
+		TargetLabel:     target,
+		TargetTypeLabel: string(targetType),
 	}
-
-	return nil
 }
@@ -1,66 +1,609 @@
 package helm
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sprokhorov/helmctl/pkg/config"
+	helmctlKubernetes "github.com/sprokhorov/helmctl/pkg/kubernetes"
+	"github.com/sprokhorov/helmctl/pkg/kubernetes/kubetest"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
-func TestHelmInstallOne(t *testing.T) {
+const testLabels = "--labels helmctl/owner=helmctl,helmctl/target-type=environments,helmctl/target=development"
+
+// newTestClient returns ShellClient for config file which executes commands with runner.
+func newTestClient(t *testing.T, file string, runner Runner, modify func(opts *ShellClientOptions)) Helm {
+	t.Helper()
+
 	log := logrus.New()
 	log.SetFormatter(&logrus.TextFormatter{
 		ForceColors:      true,
 		DisableTimestamp: true,
 	})
-	cfg := config.NewConfigFromFile("testdata/helmctl.yaml", "", log, false)
+	cfg := config.NewConfigFromFile(file, "", log, false)
 	if err := cfg.Load(); err != nil {
 		t.Fatal(err)
 	}
 
 	shellClientOptions := NewShellClientOptions(log)
-	shellClientOptions.DryRun = true
+	shellClientOptions.Runner = runner
+	if modify != nil {
+		modify(shellClientOptions)
+	}
 
 	h, err := NewShellClient(cfg, shellClientOptions)
 	if err != nil {
 		t.Fatalf("Failed to create ShellClient, %v", err)
 	}
+	return h
+}
+
+// testScript returns absolute path of test script.
+func testScript(t *testing.T, name string) string {
+	t.Helper()
+
+	path, err := filepath.Abs(filepath.Join("testdata", "scripts", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHelmInstallOne(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, func(opts *ShellClientOptions) {
+		opts.DryRun = true
+	})
 
 	in := &InstallOptions{
-		Release:    "gitlab-runner-two",
-		Target:     "development",
-		TargetType: config.TargetEnvironments,
+		Release:          "gitlab-runner-two",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
 	}
-	if err := h.Install(in); err != nil {
+	if err := h.Install(context.Background(), in); err != nil {
 		t.Fatal(err)
 	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add eclipse-iot https://eclipse.org/packages/charts",
+		"helm repo add gitlab https://charts.gitlab.io/",
+		"helm upgrade -i gitlab-runner-two --namespace gitlab-runner-two " + testLabels +
+			" -f testdata/additionalValues.yaml --set gitlabUrl=http://local-overrided:8080 --set someNumber=228" +
+			" --dry-run gitlab/gitlab-runner",
+	})
 }
 
 func TestHelmInstallAll(t *testing.T) {
-	log := logrus.New()
-	log.SetFormatter(&logrus.TextFormatter{
-		ForceColors:      true,
-		DisableTimestamp: true,
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, func(opts *ShellClientOptions) {
+		opts.DryRun = true
 	})
-	cfg := config.NewConfigFromFile("testdata/helmctl.yaml", "", log, false)
-	if err := cfg.Load(); err != nil {
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err != nil {
 		t.Fatal(err)
 	}
 
-	shellClientOptions := NewShellClientOptions(log)
-	shellClientOptions.DryRun = true
+	// repository of release with the same name and URL is added once
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add eclipse-iot https://eclipse.org/packages/charts",
+		"helm repo add gitlab https://charts.gitlab.io/",
+		"helm upgrade -i gitlab-runner-one --namespace gitlab-runner-one " + testLabels +
+			" --version 0.13.1 --dry-run gitlab/gitlab-runner",
+		"helm upgrade -i gitlab-runner-two --namespace gitlab-runner-two " + testLabels +
+			" -f testdata/additionalValues.yaml --set gitlabUrl=http://local-overrided:8080 --set someNumber=228" +
+			" --dry-run gitlab/gitlab-runner",
+	})
+}
 
-	h, err := NewShellClient(cfg, shellClientOptions)
+func TestHelmInstallFeatures(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, nil)
+
+	client := fake.NewSimpleClientset()
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: client,
+	}
+	if err := h.Install(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add private https://charts.example.com --username admin --password-stdin",
+		testScript(t, "before.sh"),
+		"helm upgrade -i app --namespace apps " + testLabels +
+			" --atomic --set-string message=configured --set replicas=2 ./charts/app",
+		testScript(t, "after.sh") + " --verbose",
+		"helm upgrade -i worker --namespace worker " + testLabels + " --version 1.2.3 private/worker",
+	})
+
+	for _, ns := range []string{"apps", "worker"} {
+		if _, err := client.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{}); err != nil {
+			t.Errorf("namespace %s was not created, %v", ns, err)
+		}
+	}
+}
+
+func TestHelmInstallScriptsDryRun(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, func(opts *ShellClientOptions) {
+		opts.DryRun = true
+		opts.SkipRepositories = true
+	})
+
+	in := &InstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm upgrade -i app --namespace apps " + testLabels +
+			" --atomic --set-string message=configured --set replicas=2 --dry-run ./charts/app",
+	})
+}
+
+func TestHelmInstallFailure(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm upgrade -i app", "Error: timed out", &fakeExitError{code: 1})
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, nil)
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err == nil {
+		t.Fatal("expected install error")
+	}
+
+	// after script and dependent release are not executed
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add private https://charts.example.com --username admin --password-stdin",
+		testScript(t, "before.sh"),
+		"helm upgrade -i app --namespace apps " + testLabels +
+			" --atomic --set-string message=configured --set replicas=2 ./charts/app",
+	})
+}
+
+func TestHelmDiff(t *testing.T) {
+	runner := (&fakeRunner{}).
+		on("helm status app", "", nil).
+		on("helm status worker", "Error: release: not found", &fakeExitError{code: 1}).
+		on("helm diff upgrade --allow-unreleased --detailed-exitcode app", "+ changed", &fakeExitError{code: 2})
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
+	results, err := h.Diff(context.Background(), &InstallOptions{Release: "all", Target: "development", TargetType: config.TargetEnvironments})
 	if err != nil {
-		t.Fatalf("Failed to create ShellClient, %v", err)
+		t.Fatal(err)
+	}
+
+	statuses := map[string]DiffStatus{"app": DiffChanged, "worker": DiffNew}
+	if len(results) != len(statuses) {
+		t.Fatalf("expected %d results, got %d", len(statuses), len(results))
+	}
+	for _, res := range results {
+		if res.Status != statuses[res.Release] {
+			t.Errorf("release %s: expected status %s, got %s", res.Release, statuses[res.Release], res.Status)
+		}
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm status app --namespace apps",
+		"helm diff upgrade --allow-unreleased --detailed-exitcode app --namespace apps" +
+			" --set-string message=configured --set replicas=2 ./charts/app",
+		"helm status worker --namespace worker",
+		"helm diff upgrade --allow-unreleased --detailed-exitcode worker --namespace worker --version 1.2.3 private/worker",
+	})
+}
+
+func TestHelmRepoAuth(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-oci.yaml", runner, nil)
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm registry login registry.example.com --username robot --password-stdin --ca-file certs/ca.crt",
+		"helm repo add internal https://charts.internal.example.com --username admin --password-stdin" +
+			" --cert-file certs/client.crt --key-file certs/client.key --insecure-skip-tls-verify --pass-credentials",
+		"helm upgrade -i app --namespace app " + testLabels + " --version 1.0.0 oci://registry.example.com/charts/app",
+		"helm upgrade -i worker --namespace worker " + testLabels + " internal/worker",
+	})
+
+	// passwords are passed with standard input only
+	for i, password := range []string{"registry-token", "secret"} {
+		if runner.commands[i+1].Stdin != password {
+			t.Errorf("command %d: unexpected standard input %q", i+1, runner.commands[i+1].Stdin)
+		}
+	}
+}
+
+func TestHelmRepoAddFailure(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm repo add", "Error: looks like repository is not valid", errors.New("exit status 1"))
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, nil)
+
+	in := &InstallOptions{Release: "all", Target: "development", TargetType: config.TargetEnvironments, KubernetesClient: fake.NewSimpleClientset()}
+	if err := h.Install(context.Background(), in); err == nil {
+		t.Fatal("expected repository error")
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add eclipse-iot https://eclipse.org/packages/charts",
+	})
+}
+
+func TestHelmInstallStop(t *testing.T) {
+	stop := make(chan struct{})
+	runner := &fakeRunner{hook: func(ctx context.Context, c *Command) error {
+		// stop while the first release is installed
+		if c.Path == "helm" && c.Args[0] == "upgrade" {
+			close(stop)
+		}
+		return nil
+	}}
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipScripts = true
+	})
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+		Stop:             stop,
+	}
+	if err := h.Install(context.Background(), in); err == nil {
+		t.Fatal("expected stop error")
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add private https://charts.example.com --username admin --password-stdin",
+		"helm upgrade -i app --namespace apps " + testLabels +
+			" --atomic --set-string message=configured --set replicas=2 ./charts/app",
+	})
+}
+
+func TestHelmInstallReleaseTimeout(t *testing.T) {
+	runner := &fakeRunner{hook: func(ctx context.Context, c *Command) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	h := newTestClient(t, "testdata/helmctl-features.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipScripts = true
+		opts.SkipRepositories = true
+	})
+
+	in := &InstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+		ReleaseTimeout:   10 * time.Millisecond,
+	}
+	err := h.Install(context.Background(), in)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestHelmInstallConfigReleaseTimeout(t *testing.T) {
+	runner := &fakeRunner{hook: func(ctx context.Context, c *Command) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	h := newTestClient(t, "testdata/helmctl-release-timeout.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
+	// releaseTimeout of environment overrides the one of release and command
+	in := &InstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+		ReleaseTimeout:   time.Hour,
+	}
+	err := h.Install(context.Background(), in)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestHelmInstallKeepGoing(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm upgrade -i gitlab-runner-one", "Error: timed out", &fakeExitError{code: 1})
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+		KeepGoing:        true,
+	}
+	if err := h.Install(context.Background(), in); err == nil {
+		t.Fatal("expected install error")
 	}
 
+	// independent release is installed after failure
+	assertArgv(t, runner.argv(), []string{
+		"helm upgrade -i gitlab-runner-one --namespace gitlab-runner-one " + testLabels +
+			" --version 0.13.1 gitlab/gitlab-runner",
+		"helm upgrade -i gitlab-runner-two --namespace gitlab-runner-two " + testLabels +
+			" -f testdata/additionalValues.yaml --set gitlabUrl=http://local-overrided:8080 --set someNumber=228" +
+			" gitlab/gitlab-runner",
+	})
+}
+
+func TestHelmInstallHooks(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-hooks.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
 	in := &InstallOptions{
-		Release:    "all",
-		Target:     "development",
-		TargetType: config.TargetEnvironments,
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
 	}
-	if err := h.Install(in); err != nil {
+	if err := h.Install(context.Background(), in); err != nil {
 		t.Fatal(err)
 	}
+
+	// global hooks are executed before target ones
+	assertArgv(t, runner.argv(), []string{
+		"sh -c echo global before sh",
+		testScript(t, "before.sh"),
+		"helm upgrade -i app --namespace apps " + testLabels + " ./charts/app",
+		"sh -c echo global after sh",
+	})
+}
+
+func TestHelmInstallFailureHooks(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm upgrade -i app", "Error: timed out", &fakeExitError{code: 1})
+	h := newTestClient(t, "testdata/helmctl-hooks.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err == nil {
+		t.Fatal("expected install error")
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"sh -c echo global before sh",
+		testScript(t, "before.sh"),
+		"helm upgrade -i app --namespace apps " + testLabels + " ./charts/app",
+		"sh -c echo app failure sh",
+		"sh -c echo global failure sh",
+		"sh -c echo development failure sh",
+	})
+
+	// failure scripts get error text in environment
+	for _, c := range runner.commands[3:] {
+		if !hasEnvPrefix(c.Env, "HELMCTL_ERROR=") {
+			t.Errorf("%s has no HELMCTL_ERROR in environment %v", argvString(c), c.Env)
+		}
+	}
+}
+
+func TestHelmInstallFailureHooksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// commands fail as processes do when context is cancelled
+	cancelled := map[string]bool{}
+	runner := &fakeRunner{hook: func(ctx context.Context, c *Command) error {
+		cancelled[argvString(c)] = ctx.Err() != nil
+		return ctx.Err()
+	}}
+	h := newTestClient(t, "testdata/helmctl-hooks.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+	})
+
+	for _, release := range []string{"app", "all"} {
+		in := &InstallOptions{
+			Release:          release,
+			Target:           "development",
+			TargetType:       config.TargetEnvironments,
+			KubernetesClient: fake.NewSimpleClientset(),
+		}
+		if err := h.Install(ctx, in); err == nil {
+			t.Fatalf("%s: expected install error", release)
+		}
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm upgrade -i app --namespace apps " + testLabels + " ./charts/app",
+		"sh -c echo app failure sh",
+		"sh -c echo global before sh",
+		"sh -c echo global failure sh",
+		"sh -c echo development failure sh",
+	})
+	for _, failure := range []string{"app", "global", "development"} {
+		argv := "sh -c echo " + failure + " failure sh"
+		if cancelled[argv] {
+			t.Errorf("%s is executed with cancelled context", argv)
+		}
+	}
+}
+
+func TestHelmInstallProjectHooks(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-hooks.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+		opts.SkipScripts = true
+	})
+
+	in := &InstallOptions{
+		Release:          "all",
+		Target:           "platform",
+		TargetType:       config.TargetProjects,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	// hooks are scripts and skipped with them
+	assertArgv(t, runner.argv(), []string{
+		"helm upgrade -i app --namespace apps" +
+			" --labels helmctl/owner=helmctl,helmctl/target-type=projects,helmctl/target=platform ./charts/app",
+	})
+}
+
+func hasEnvPrefix(env []string, prefix string) bool {
+	for _, e := range env {
+		if strings.HasPrefix(e, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHelmInstallOptions(t *testing.T) {
+	for _, tt := range []struct {
+		env  string
+		args string
+	}{
+		{
+			"development",
+			"helm upgrade -i app --namespace apps " + testLabels +
+				" --wait --wait-for-jobs --force --cleanup-on-fail --skip-crds --reuse-values --no-hooks" +
+				" --timeout 10m --history-max 5 --description deployed by helmctl" +
+				" --render-subchart-notes ./charts/app",
+		},
+		{
+			"production",
+			"helm upgrade -i app --namespace apps" +
+				" --labels helmctl/owner=helmctl,helmctl/target-type=environments,helmctl/target=production" +
+				" --wait-for-jobs --force --cleanup-on-fail --skip-crds --reuse-values --no-hooks" +
+				" --timeout 20m --history-max 0 --description deployed by helmctl" +
+				" --render-subchart-notes --debug ./charts/app",
+		},
+	} {
+		t.Run(tt.env, func(t *testing.T) {
+			runner := &fakeRunner{}
+			h := newTestClient(t, "testdata/helmctl-options.yaml", runner, func(opts *ShellClientOptions) {
+				opts.SkipRepositories = true
+			})
+
+			in := &InstallOptions{
+				Release:          "app",
+				Target:           tt.env,
+				TargetType:       config.TargetEnvironments,
+				KubernetesClient: fake.NewSimpleClientset(),
+			}
+			if err := h.Install(context.Background(), in); err != nil {
+				t.Fatal(err)
+			}
+
+			assertArgv(t, runner.argv(), []string{tt.args})
+		})
+	}
+}
+
+func TestHelmUninstallDeleteNamespace(t *testing.T) {
+	namespaces := []runtime.Object{}
+	for _, ns := range []string{"apps", "cache"} {
+		namespaces = append(namespaces, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   ns,
+			Labels: map[string]string{helmctlKubernetes.ManagedByLabel: helmctlKubernetes.ManagedByValue},
+		}})
+	}
+	client := fake.NewSimpleClientset(append(namespaces,
+		kubetest.HelmReleaseSecret("app", "apps", 1, "deployed", "0.1.0"),
+		kubetest.HelmReleaseSecret("worker", "apps", 1, "deployed", "0.1.0"),
+		kubetest.HelmReleaseSecret("cache", "cache", 1, "deployed", "0.1.0"),
+		kubetest.HelmReleaseSecret("legacy", "cache", 1, "deployed", "0.1.0"),
+	)...)
+
+	exists := func(ns string) bool {
+		_, err := client.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// namespace is kept while other release is stored in it
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-uninstall.yaml", runner, nil)
+	in := &UninstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		DeleteNamespace:  true,
+		KubernetesClient: client,
+	}
+	if err := h.Uninstall(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("apps") {
+		t.Error("namespace apps with release worker was deleted")
+	}
+
+	// namespace is deleted after all its releases are uninstalled,
+	// namespace with release not managed by the target is kept
+	runner = &fakeRunner{}
+	h = newTestClient(t, "testdata/helmctl-uninstall.yaml", runner, nil)
+	in.Release = "all"
+	if err := h.Uninstall(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	assertArgv(t, runner.argv(), []string{
+		"helm uninstall cache --namespace cache",
+		"helm uninstall worker --namespace apps",
+		"helm uninstall app --namespace apps",
+	})
+	if exists("apps") {
+		t.Error("namespace apps was not deleted")
+	}
+	if !exists("cache") {
+		t.Error("namespace cache with release legacy was deleted")
+	}
 }
@@ -0,0 +1,84 @@
+package helm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// DefaultHistoryFile is a name of file with recorded helmctl runs.
+const DefaultHistoryFile = ".helmctl-history.json"
+
+// History contains last helmctl install run of every target.
+type History struct {
+	Runs map[string]*Run `json:"runs"`
+}
+
+// Run contains releases touched by helmctl install run.
+type Run struct {
+	Target     string            `json:"target"`
+	TargetType config.TargetType `json:"targetType"`
+	Time       time.Time         `json:"time"`
+	Releases   []*RunRelease     `json:"releases"`
+
+	mu sync.Mutex
+}
+
+// RunRelease contains release revision installed before the run.
+// Zero PreviousRevision means release was not installed before.
+type RunRelease struct {
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	PreviousRevision int    `json:"previousRevision"`
+}
+
+// LoadHistory loads history from file, missing file is an empty history.
+func LoadHistory(path string) (*History, error) {
+	h := &History{Runs: map[string]*Run{}}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, h); err != nil {
+		return nil, err
+	}
+	if h.Runs == nil {
+		h.Runs = map[string]*Run{}
+	}
+
+	return h, nil
+}
+
+// Save writes history to file.
+func (h *History) Save(path string) error {
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// LastRun returns last recorded run of the target or nil.
+func (h *History) LastRun(target string, targetType config.TargetType) *Run {
+	return h.Runs[historyKey(target, targetType)]
+}
+
+// SetRun records run as the last run of its target.
+func (h *History) SetRun(run *Run) {
+	h.Runs[historyKey(run.Target, run.TargetType)] = run
+}
+
+// historyKey returns history key of the target.
+func historyKey(target string, targetType config.TargetType) string {
+	return string(targetType) + "/" + target
+}
@@ -0,0 +1,43 @@
+package helm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultHistoryFile)
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("missing history file must be loaded as empty history, %v", err)
+	}
+	if h.LastRun("development", config.TargetEnvironments) != nil {
+		t.Fatal("empty history contains run")
+	}
+
+	h.SetRun(&Run{
+		Target:     "development",
+		TargetType: config.TargetEnvironments,
+		Time:       time.Now(),
+		Releases:   []*RunRelease{{Name: "app", Namespace: "apps", PreviousRevision: 3}},
+	})
+	if err := h.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err = LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.LastRun("development", config.TargetProjects) != nil {
+		t.Error("run is found for wrong target type")
+	}
+	run := h.LastRun("development", config.TargetEnvironments)
+	if run == nil || len(run.Releases) != 1 || run.Releases[0].PreviousRevision != 3 {
+		t.Errorf("unexpected run %+v", run)
+	}
+}
@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// LintOptions contains arguments for Lint method.
+type LintOptions struct {
+	Target     string
+	TargetType config.TargetType
+
+	// lock contains locked chart versions, nil if there is no lock file.
+	lock *Lock
+}
+
+// NewLintOptions creates new LintOptions object.
+func NewLintOptions() *LintOptions {
+	return &LintOptions{TargetType: config.TargetEnvironments}
+}
+
+// LintResult contains helm lint result of a release.
+type LintResult struct {
+	Target     string
+	TargetType config.TargetType
+	Release    string
+	Passed     bool
+	Output     string
+}
+
+// Lint runs helm lint for every release of the target with release values.
+// Failed linting is reported in results, error is returned only if linting
+// cannot be started.
+func (sc *ShellClient) Lint(ctx context.Context, in *LintOptions) ([]*LintResult, error) {
+	defer sc.decryptedRemove()
+	defer sc.chartsRemove()
+
+	if err := sc.targetReposSync(ctx, "all", in.Target, in.TargetType); err != nil {
+		sc.l.Errorf("Failed to add helm repositories, %v", err)
+		return nil, err
+	}
+
+	lock, err := sc.lockLoad()
+	if err != nil {
+		return nil, err
+	}
+	in.lock = lock
+
+	releases, err := sc.cfg.TargetReleases(in.Target, in.TargetType)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*LintResult{}
+	for _, r := range releases {
+		result := &LintResult{Target: in.Target, TargetType: in.TargetType, Release: r.Name}
+		out, err := sc.releaseLint(ctx, r, in)
+		result.Output = out
+		result.Passed = err == nil
+		if err != nil {
+			sc.l.Errorf("Release %s linting failed, %v", r.Name, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// releaseLint runs helm lint for release and returns its output.
+func (sc *ShellClient) releaseLint(ctx context.Context, r *config.Release, in *LintOptions) (string, error) {
+	sc.l.Infof("Lint helm release %s", r.Name)
+
+	// pin locked chart version
+	if err := sc.lockCheck(in.lock, r, in.Target, in.TargetType); err != nil {
+		return "", err
+	}
+
+	// decrypt sops
+	if err := sc.sopsDecrypt(r.ValueFiles); err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "helmctl-lint-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	return sc.backend.lint(ctx, sc, r, dir)
+}
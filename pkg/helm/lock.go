@@ -0,0 +1,511 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// DefaultLockFile is a name of file with locked chart versions.
+const DefaultLockFile = "helmctl.lock"
+
+// Lock contains exact chart versions and digests of releases by targets.
+type Lock struct {
+	Generated time.Time                          `yaml:"generated"`
+	Targets   map[string]map[string]*LockedChart `yaml:"targets"`
+
+	mu      sync.Mutex
+	changed bool
+}
+
+// LockedChart contains chart version resolved from repository index.
+// Digest is SHA-256 of chart archive as published in repository index, it is
+// computed from the archive only if index has no digest.
+type LockedChart struct {
+	Chart      string `yaml:"chart"`
+	Repository string `yaml:"repository"`
+	Version    string `yaml:"version"`
+	Digest     string `yaml:"digest"`
+}
+
+// LockOptions contains arguments for Lock method.
+type LockOptions struct {
+	// Target is locked, all targets are locked if empty.
+	Target     string
+	TargetType config.TargetType
+}
+
+// NewLockOptions creates new LockOptions object.
+func NewLockOptions() *LockOptions {
+	return &LockOptions{TargetType: config.TargetEnvironments}
+}
+
+// LoadLock loads lock from file, missing file is an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	l := &Lock{Targets: map[string]map[string]*LockedChart{}}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("cannot parse lock file %s, %v", path, err)
+	}
+	if l.Targets == nil {
+		l.Targets = map[string]map[string]*LockedChart{}
+	}
+
+	return l, nil
+}
+
+// Save writes lock to file.
+func (l *Lock) Save(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.Generated = time.Now().UTC()
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+// Get returns locked chart of release or nil.
+func (l *Lock) Get(target string, targetType config.TargetType, release string) *LockedChart {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.Targets[historyKey(target, targetType)][release]
+}
+
+// Set locks chart of release.
+func (l *Lock) Set(target string, targetType config.TargetType, release string, lc *LockedChart) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := historyKey(target, targetType)
+	if l.Targets[key] == nil {
+		l.Targets[key] = map[string]*LockedChart{}
+	}
+	l.Targets[key][release] = lc
+	l.changed = true
+}
+
+// lockSave writes lock changed by install to lock file.
+func (sc *ShellClient) lockSave(lock *Lock) {
+	lock.mu.Lock()
+	changed := lock.changed
+	lock.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if err := lock.Save(sc.opts.LockFile); err != nil {
+		sc.l.Warnf("Cannot save lock file, %v", err)
+	}
+}
+
+// Lock resolves charts of target releases to exact versions and digests
+// and writes them to lock file. Local and OCI charts are not locked.
+func (sc *ShellClient) Lock(ctx context.Context, in *LockOptions) error {
+	if sc.opts.LockFile == "" {
+		return fmt.Errorf("lock file is not set")
+	}
+
+	lock, err := LoadLock(sc.opts.LockFile)
+	if err != nil {
+		return err
+	}
+
+	targets := map[config.TargetType][]string{in.TargetType: {in.Target}}
+	if in.Target == "" {
+		targets = map[config.TargetType][]string{
+			config.TargetEnvironments: sc.cfg.Environments(),
+			config.TargetProjects:     sc.cfg.Projects(),
+		}
+	}
+
+	for _, targetType := range []config.TargetType{config.TargetEnvironments, config.TargetProjects} {
+		for _, target := range targets[targetType] {
+			releases, err := sc.cfg.TargetReleases(target, targetType)
+			if err != nil {
+				return err
+			}
+
+			// releases removed from target are removed from lock
+			delete(lock.Targets, historyKey(target, targetType))
+			for _, r := range releases {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				lc, err := sc.charts.resolve(r, r.Version)
+				if err != nil {
+					return fmt.Errorf("%s %s release %s: %v", targetType, target, r.Name, err)
+				}
+				if lc == nil {
+					sc.l.Debugf("Skip locking of release %s with local or OCI chart %s", r.Name, r.Chart)
+					continue
+				}
+				sc.l.Infof("Lock release %s of %s %s to chart %s %s", r.Name, targetType, target, r.Chart, lc.Version)
+				lock.Set(target, targetType, r.Name, lc)
+			}
+		}
+	}
+
+	return lock.Save(sc.opts.LockFile)
+}
+
+// lockLoad loads lock file for install, diff, template and lint, nil is
+// returned if there is no lock file and lock is not updated.
+func (sc *ShellClient) lockLoad() (*Lock, error) {
+	if sc.opts.LockFile == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(sc.opts.LockFile); os.IsNotExist(err) && !sc.opts.UpdateLock {
+		return nil, nil
+	}
+
+	return LoadLock(sc.opts.LockFile)
+}
+
+// lockCheck sets release version to the locked one and checks that digests
+// of the chart in repository index and of its archive are not changed, the
+// verified archive is passed to helm. Chart is locked again with UpdateLock option.
+func (sc *ShellClient) lockCheck(lock *Lock, r *config.Release, target string, targetType config.TargetType) error {
+	if lock == nil {
+		return nil
+	}
+
+	if sc.opts.UpdateLock {
+		lc, err := sc.charts.resolve(r, r.Version)
+		if err != nil || lc == nil {
+			return err
+		}
+		if err := sc.charts.verify(r, lc); err != nil {
+			return err
+		}
+		if locked := lock.Get(target, targetType, r.Name); locked == nil || *locked != *lc {
+			sc.l.Infof("Update lock of release %s to chart %s %s", r.Name, lc.Chart, lc.Version)
+			lock.Set(target, targetType, r.Name, lc)
+		}
+		r.Version = lc.Version
+		return nil
+	}
+
+	locked := lock.Get(target, targetType, r.Name)
+	if locked == nil {
+		if _, name := sc.charts.repository(r); name != "" {
+			sc.l.Warnf("Release %s is not locked, run helmctl lock or use --update-lock", r.Name)
+		}
+		return nil
+	}
+	if locked.Chart != r.Chart {
+		return fmt.Errorf("release chart %s differs from locked chart %s, run helmctl lock or use --update-lock", r.Chart, locked.Chart)
+	}
+	if r.Version != "" {
+		c, err := semver.NewConstraint(r.Version)
+		if err != nil {
+			return err
+		}
+		v, err := semver.NewVersion(locked.Version)
+		if err != nil {
+			return err
+		}
+		if !c.Check(v) {
+			return fmt.Errorf("locked version %s does not match release version %s, run helmctl lock or use --update-lock", locked.Version, r.Version)
+		}
+	}
+
+	lc, err := sc.charts.resolve(r, locked.Version)
+	if err != nil {
+		return err
+	}
+	if lc == nil {
+		return fmt.Errorf("repository of locked chart %s is not found", locked.Chart)
+	}
+	if lc.Digest != locked.Digest {
+		return fmt.Errorf("digest %s of chart %s %s differs from locked digest %s, use --update-lock to accept it", lc.Digest, r.Chart, lc.Version, locked.Digest)
+	}
+	if err := sc.charts.verify(r, locked); err != nil {
+		return err
+	}
+
+	r.Version = locked.Version
+	return nil
+}
+
+// chartRef returns chart and version passed to helm. Verified archive of
+// locked chart is installed instead of the chart from repository.
+func (sc *ShellClient) chartRef(r *config.Release) (string, string) {
+	if path, ok := sc.charts.archive(r.Chart, r.Version); ok {
+		return path, ""
+	}
+	return r.Chart, r.Version
+}
+
+// chartsRemove removes verified chart archives.
+func (sc *ShellClient) chartsRemove() {
+	if err := sc.charts.remove(); err != nil {
+		sc.l.Warnf("Failed to remove chart archives, %v", err)
+	}
+}
+
+// chartResolver resolves chart versions from repository indexes, indexes
+// are downloaded once. Archives of locked charts are downloaded to a temp
+// directory and verified before they are installed.
+type chartResolver struct {
+	sc      *ShellClient
+	mu      sync.Mutex
+	indexes map[string]*repo.IndexFile
+
+	archivesMu sync.Mutex
+	dir        string
+	// archives maps chart with version to verified archive.
+	archives map[string]string
+}
+
+// repository returns repository and name of release chart, name is empty
+// for local and OCI charts.
+func (cr *chartResolver) repository(r *config.Release) (*config.Repository, string) {
+	if isLocalChart(r.Chart) || registry.IsOCI(r.Chart) {
+		return nil, ""
+	}
+
+	for _, repo := range cr.sc.repositories([]*config.Release{r}) {
+		if strings.HasPrefix(r.Chart, repo.Name+"/") && !registry.IsOCI(repo.URL) {
+			return repo, strings.TrimPrefix(r.Chart, repo.Name+"/")
+		}
+	}
+	return nil, ""
+}
+
+// resolve returns chart version matching version constraint with its digest.
+// Nil is returned for local and OCI charts.
+func (cr *chartResolver) resolve(r *config.Release, version string) (*LockedChart, error) {
+	repoCfg, name := cr.repository(r)
+	if name == "" {
+		return nil, nil
+	}
+
+	index, err := cr.index(repoCfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load index of repository %s, %v", repoCfg.Name, err)
+	}
+	cv, err := index.Get(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("chart %s: %v", r.Chart, err)
+	}
+
+	digest := cv.Digest
+	if digest == "" {
+		if len(cv.URLs) == 0 {
+			return nil, fmt.Errorf("chart %s %s has no URLs in repository index", r.Chart, cv.Version)
+		}
+		data, err := cr.archiveGet(repoCfg, cv.URLs[0])
+		if err != nil {
+			return nil, fmt.Errorf("chart %s %s: %v", r.Chart, cv.Version, err)
+		}
+		digest = archiveDigest(data)
+	}
+
+	return &LockedChart{Chart: r.Chart, Repository: repoCfg.URL, Version: cv.Version, Digest: digest}, nil
+}
+
+// verify downloads archive of locked chart and checks its digest, so archive
+// served by repository must be the locked one and not only its index entry.
+// Archive of chart version is downloaded once.
+func (cr *chartResolver) verify(r *config.Release, lc *LockedChart) error {
+	if _, ok := cr.archive(r.Chart, lc.Version); ok {
+		return nil
+	}
+
+	repoCfg, name := cr.repository(r)
+	if name == "" {
+		return fmt.Errorf("repository of locked chart %s is not found", lc.Chart)
+	}
+	index, err := cr.index(repoCfg)
+	if err != nil {
+		return fmt.Errorf("cannot load index of repository %s, %v", repoCfg.Name, err)
+	}
+	cv, err := index.Get(name, lc.Version)
+	if err != nil {
+		return fmt.Errorf("chart %s: %v", r.Chart, err)
+	}
+	if len(cv.URLs) == 0 {
+		return fmt.Errorf("chart %s %s has no URLs in repository index", r.Chart, cv.Version)
+	}
+
+	data, err := cr.archiveGet(repoCfg, cv.URLs[0])
+	if err != nil {
+		return fmt.Errorf("chart %s %s: %v", r.Chart, cv.Version, err)
+	}
+	if digest := archiveDigest(data); digest != lc.Digest {
+		return fmt.Errorf("digest %s of downloaded chart %s %s differs from locked digest %s", digest, r.Chart, lc.Version, lc.Digest)
+	}
+
+	cr.archivesMu.Lock()
+	defer cr.archivesMu.Unlock()
+
+	if cr.dir == "" {
+		dir, err := ioutil.TempDir("", "helmctl-charts-")
+		if err != nil {
+			return err
+		}
+		cr.dir = dir
+		cr.archives = map[string]string{}
+	}
+	path := filepath.Join(cr.dir, fmt.Sprintf("%d-%s", len(cr.archives), filepath.Base(cv.URLs[0])))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	cr.archives[r.Chart+"@"+lc.Version] = path
+
+	return nil
+}
+
+// archive returns path of verified archive of chart version.
+func (cr *chartResolver) archive(chart, version string) (string, bool) {
+	cr.archivesMu.Lock()
+	defer cr.archivesMu.Unlock()
+
+	path, ok := cr.archives[chart+"@"+version]
+	return path, ok
+}
+
+// remove removes verified archives with temp directory.
+func (cr *chartResolver) remove() error {
+	cr.archivesMu.Lock()
+	defer cr.archivesMu.Unlock()
+
+	if cr.dir == "" {
+		return nil
+	}
+
+	dir := cr.dir
+	cr.dir = ""
+	cr.archives = nil
+	return os.RemoveAll(dir)
+}
+
+// index returns index of repository. Index of file:// repository is read
+// from its directory.
+func (cr *chartResolver) index(repoCfg *config.Repository) (*repo.IndexFile, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if index, ok := cr.indexes[repoCfg.URL]; ok {
+		return index, nil
+	}
+
+	var index *repo.IndexFile
+	if path, ok := localRepoPath(repoCfg.URL); ok {
+		var err error
+		if index, err = repo.LoadIndexFile(strings.TrimSuffix(path, "/") + "/index.yaml"); err != nil {
+			return nil, err
+		}
+	} else {
+		dir, err := ioutil.TempDir("", "helmctl-index-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		chartRepo, err := repo.NewChartRepository(repoEntry(repoCfg), getter.All(cli.New()))
+		if err != nil {
+			return nil, err
+		}
+		chartRepo.CachePath = dir
+		path, err := chartRepo.DownloadIndexFile()
+		if err != nil {
+			return nil, err
+		}
+		if index, err = repo.LoadIndexFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cr.indexes[repoCfg.URL] = index
+	return index, nil
+}
+
+// archiveDigest returns SHA-256 digest of chart archive.
+func archiveDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// archiveGet downloads chart archive.
+func (cr *chartResolver) archiveGet(repoCfg *config.Repository, ref string) ([]byte, error) {
+	u, err := repo.ResolveReferenceURL(repoCfg.URL, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if path, ok := localRepoPath(u); ok {
+		return ioutil.ReadFile(path)
+	}
+
+	g, err := getter.All(cli.New()).ByScheme(strings.SplitN(u, "://", 2)[0])
+	if err != nil {
+		return nil, err
+	}
+	archive, err := g.Get(u,
+		getter.WithURL(repoCfg.URL),
+		getter.WithBasicAuth(repoCfg.User, repoCfg.Password),
+		getter.WithPassCredentialsAll(repoCfg.PassCredentials),
+		getter.WithTLSClientConfig(repoCfg.CertFile, repoCfg.KeyFile, repoCfg.CAFile),
+		getter.WithInsecureSkipVerifyTLS(repoCfg.InsecureSkipTLSVerify),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
+
+// localRepoPath returns path of file:// URL.
+func localRepoPath(u string) (string, bool) {
+	if !strings.HasPrefix(u, "file://") {
+		return "", false
+	}
+	return strings.TrimPrefix(u, "file://"), true
+}
+
+// repoEntry returns helm repository entry of repository.
+func repoEntry(repoCfg *config.Repository) *repo.Entry {
+	return &repo.Entry{
+		Name:                  repoCfg.Name,
+		URL:                   repoCfg.URL,
+		Username:              repoCfg.User,
+		Password:              repoCfg.Password,
+		CAFile:                repoCfg.CAFile,
+		CertFile:              repoCfg.CertFile,
+		KeyFile:               repoCfg.KeyFile,
+		InsecureSkipTLSverify: repoCfg.InsecureSkipTLSVerify,
+		PassCredentialsAll:    repoCfg.PassCredentials,
+	}
+}
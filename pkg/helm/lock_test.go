@@ -0,0 +1,216 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testDigest100 = "7ab2225f7c9c051adb91f1e27414a9a7acc05dcbd34ceb961acaf71a415c529a"
+	testDigest110 = "299383aed0c3554c2ed9211976dd4be81a35b43e2e24815e9b8306c85370a870"
+)
+
+func TestLock(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), DefaultLockFile)
+	h := newTestClient(t, "testdata/helmctl-lock.yaml", &fakeRunner{}, func(opts *ShellClientOptions) {
+		opts.LockFile = lockFile
+	})
+
+	if err := h.Lock(context.Background(), NewLockOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := LoadLock(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*LockedChart{
+		"app":    {Chart: "local/app", Repository: "file://testdata/repo", Version: "1.1.0", Digest: testDigest110},
+		"pinned": {Chart: "local/app", Repository: "file://testdata/repo", Version: "1.0.0", Digest: testDigest100},
+	}
+	got := lock.Targets[historyKey("development", config.TargetEnvironments)]
+	if len(got) != len(want) {
+		t.Fatalf("expected %d locked releases, got %d", len(want), len(got))
+	}
+	for name, lc := range want {
+		if got[name] == nil || *got[name] != *lc {
+			t.Errorf("release %s: expected %+v, got %+v", name, lc, got[name])
+		}
+	}
+}
+
+func TestInstallLock(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), DefaultLockFile)
+	lock := &Lock{Targets: map[string]map[string]*LockedChart{}}
+	lock.Set("development", config.TargetEnvironments, "app", &LockedChart{
+		Chart: "local/app", Repository: "file://testdata/repo", Version: "1.0.0", Digest: testDigest100,
+	})
+	lock.Set("development", config.TargetEnvironments, "pinned", &LockedChart{
+		Chart: "local/app", Repository: "file://testdata/repo", Version: "1.0.0", Digest: "changed",
+	})
+	if err := lock.Save(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	install := func(release string, updateLock bool) (*fakeRunner, error) {
+		runner := &fakeRunner{}
+		h := newTestClient(t, "testdata/helmctl-lock.yaml", runner, func(opts *ShellClientOptions) {
+			opts.LockFile = lockFile
+			opts.UpdateLock = updateLock
+			opts.SkipRepositories = true
+		})
+		return runner, h.Install(context.Background(), &InstallOptions{
+			Release:          release,
+			Target:           "development",
+			TargetType:       config.TargetEnvironments,
+			KubernetesClient: fake.NewSimpleClientset(),
+		})
+	}
+
+	// verified archive of locked version is installed instead of the latest matching one
+	runner, err := install("app", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argv := runner.argv()
+	if len(argv) != 1 || !strings.HasPrefix(argv[0], "helm upgrade -i app --namespace app "+testLabels+" /") ||
+		!strings.HasSuffix(argv[0], "-app-1.0.0.tgz") {
+		t.Errorf("locked archive is not installed: %v", argv)
+	}
+	// archive is removed after install
+	if _, err := os.Stat(runner.commands[0].Args[len(runner.commands[0].Args)-1]); !os.IsNotExist(err) {
+		t.Errorf("archive is not removed, %v", err)
+	}
+
+	// chart with changed digest is not installed
+	runner, err = install("pinned", false)
+	if err == nil || !strings.Contains(err.Error(), "differs from locked digest") {
+		t.Fatalf("expected digest error, got %v", err)
+	}
+	assertArgv(t, runner.argv(), []string{})
+
+	// digest is accepted and lock is updated
+	if _, err = install("pinned", true); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = LoadLock(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc := lock.Get("development", config.TargetEnvironments, "pinned"); lc.Digest != testDigest100 {
+		t.Errorf("lock is not updated, got digest %s", lc.Digest)
+	}
+}
+
+func TestDiffTemplateLintLock(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), DefaultLockFile)
+	lock := &Lock{Targets: map[string]map[string]*LockedChart{}}
+	lock.Set("development", config.TargetEnvironments, "app", &LockedChart{
+		Chart: "local/app", Repository: "file://testdata/repo", Version: "1.0.0", Digest: testDigest100,
+	})
+	if err := lock.Save(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-lock.yaml", runner, func(opts *ShellClientOptions) {
+		opts.LockFile = lockFile
+		opts.SkipRepositories = true
+	})
+
+	in := &InstallOptions{Release: "app", Target: "development", TargetType: config.TargetEnvironments}
+	if _, err := h.Diff(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	tin := &TemplateOptions{Release: "app", Target: "development", TargetType: config.TargetEnvironments, OutputDir: t.TempDir()}
+	if err := h.Template(context.Background(), tin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Lint(context.Background(), &LintOptions{Target: "development", TargetType: config.TargetEnvironments}); err != nil {
+		t.Fatal(err)
+	}
+
+	// verified archive of locked version is used instead of the latest matching one
+	for _, prefix := range []string{"helm diff upgrade", "helm template app", "helm lint"} {
+		found := false
+		for _, argv := range runner.argv() {
+			if strings.HasPrefix(argv, prefix) {
+				found = true
+				if !strings.Contains(argv, "-app-1.0.0.tgz") {
+					t.Errorf("locked archive is not used: %s", argv)
+				}
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s is not executed", prefix)
+		}
+	}
+}
+
+func TestInstallLockTamperedArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	// index digest is the locked one, but repository serves another archive
+	index := `apiVersion: v1
+entries:
+  app:
+    - apiVersion: v2
+      name: app
+      version: 1.0.0
+      digest: ` + testDigest100 + `
+      urls:
+        - app-1.0.0.tgz
+`
+	cfg := `version: v1
+spec:
+  repositories:
+    - name: local
+      url: file://` + dir + `
+  releases:
+    - name: app
+      chart: local/app
+  installs:
+    environments:
+      development:
+        - app
+`
+	files := map[string]string{"index.yaml": index, "app-1.0.0.tgz": "tampered", "helmctl.yaml": cfg}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lockFile := filepath.Join(dir, DefaultLockFile)
+	lock := &Lock{Targets: map[string]map[string]*LockedChart{}}
+	lock.Set("development", config.TargetEnvironments, "app", &LockedChart{
+		Chart: "local/app", Repository: "file://" + dir, Version: "1.0.0", Digest: testDigest100,
+	})
+	if err := lock.Save(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeRunner{}
+	h := newTestClient(t, filepath.Join(dir, "helmctl.yaml"), runner, func(opts *ShellClientOptions) {
+		opts.LockFile = lockFile
+		opts.SkipRepositories = true
+	})
+	err := h.Install(context.Background(), &InstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "digest "+archiveDigest([]byte("tampered"))+" of downloaded chart") {
+		t.Fatalf("expected archive digest error, got %v", err)
+	}
+	assertArgv(t, runner.argv(), []string{})
+}
@@ -0,0 +1,283 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// PostRenderCommand is hidden helmctl command used as helm post-renderer.
+const PostRenderCommand = "post-render"
+
+// PostRenderer applies labels, annotations and patches of release post render
+// to rendered manifests. It implements helm post-renderer.
+type PostRenderer struct {
+	labels      map[string]string
+	annotations map[string]string
+	patches     []*patch
+}
+
+// patch is loaded patch of post render.
+type patch struct {
+	name   string
+	typ    string
+	data   []byte
+	target *config.PatchTarget
+}
+
+// NewPostRenderer creates new PostRenderer object, patch files are read.
+func NewPostRenderer(pr *config.PostRender) (*PostRenderer, error) {
+	p := &PostRenderer{labels: pr.Labels, annotations: pr.Annotations}
+
+	for idx, cp := range pr.Patches {
+		name := cp.Path
+		data := []byte(cp.Patch)
+		if cp.Path != "" {
+			var err error
+			if data, err = os.ReadFile(cp.Path); err != nil {
+				return nil, err
+			}
+		} else {
+			name = fmt.Sprintf("patch %d", idx)
+		}
+
+		// patches could be defined in YAML or JSON
+		data, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		target := cp.Target
+		if target == nil {
+			// strategic merge patch targets resource it defines
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(data); err != nil {
+				return nil, fmt.Errorf("%s: patch without target must have kind and name, %v", name, err)
+			}
+			target = &config.PatchTarget{Kind: obj.GetKind(), Name: obj.GetName(), Namespace: obj.GetNamespace()}
+		}
+
+		p.patches = append(p.patches, &patch{name: name, typ: cp.GetType(), data: data, target: target})
+	}
+
+	return p, nil
+}
+
+// Run applies changes to manifests, every patch must match a resource.
+func (p *PostRenderer) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
+	objs, err := manifestsDecode(in)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]bool, len(p.patches))
+	if err := p.apply(objs, matched); err != nil {
+		return nil, err
+	}
+	if err := p.checkMatched(matched); err != nil {
+		return nil, err
+	}
+
+	return manifestsEncode(objs)
+}
+
+// RunDir applies changes to manifests written to dir by helm template.
+func (p *PostRenderer) RunDir(dir string) error {
+	matched := make([]bool, len(p.patches))
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		objs, err := manifestsDecode(bytes.NewBuffer(data))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err := p.apply(objs, matched); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		out, err := manifestsEncode(objs)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, out.Bytes(), 0o644)
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.checkMatched(matched)
+}
+
+// apply adds labels and annotations to resources and applies patches to matching ones.
+func (p *PostRenderer) apply(objs []*unstructured.Unstructured, matched []bool) error {
+	for _, obj := range objs {
+		if len(p.labels) > 0 {
+			obj.SetLabels(mergeMaps(obj.GetLabels(), p.labels))
+		}
+		if len(p.annotations) > 0 {
+			obj.SetAnnotations(mergeMaps(obj.GetAnnotations(), p.annotations))
+		}
+	}
+
+	for idx, pt := range p.patches {
+		for _, obj := range objs {
+			if !pt.matches(obj) {
+				continue
+			}
+			if err := pt.apply(obj); err != nil {
+				return fmt.Errorf("%s: %s %s: %v", pt.name, obj.GetKind(), obj.GetName(), err)
+			}
+			matched[idx] = true
+		}
+	}
+
+	return nil
+}
+
+// checkMatched returns error if a patch did not match any resource.
+func (p *PostRenderer) checkMatched(matched []bool) error {
+	for idx, ok := range matched {
+		if !ok {
+			t := p.patches[idx].target
+			return fmt.Errorf("%s: no resources match kind %q, name %q, namespace %q", p.patches[idx].name, t.Kind, t.Name, t.Namespace)
+		}
+	}
+	return nil
+}
+
+// matches returns true if resource is selected by patch target.
+func (pt *patch) matches(obj *unstructured.Unstructured) bool {
+	t := pt.target
+	return (t.Kind == "" || t.Kind == obj.GetKind()) &&
+		(t.Name == "" || t.Name == obj.GetName()) &&
+		(t.Namespace == "" || t.Namespace == obj.GetNamespace())
+}
+
+// apply applies patch to resource.
+func (pt *patch) apply(obj *unstructured.Unstructured) error {
+	original, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch pt.typ {
+	case config.PatchJSON6902:
+		ops, err := jsonpatch.DecodePatch(pt.data)
+		if err != nil {
+			return err
+		}
+		if patched, err = ops.Apply(original); err != nil {
+			return err
+		}
+	default:
+		if patched, err = strategicMergePatch(obj, original, pt.data); err != nil {
+			return err
+		}
+	}
+
+	return obj.UnmarshalJSON(patched)
+}
+
+// strategicMergePatch applies strategic merge patch to resource of built-in
+// kind, custom resources have no patch strategy so JSON merge patch is used.
+func strategicMergePatch(obj *unstructured.Unstructured, original, data []byte) ([]byte, error) {
+	gvk := schema.FromAPIVersionAndKind(obj.GetAPIVersion(), obj.GetKind())
+	typed, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return jsonpatch.MergePatch(original, data)
+	}
+	return strategicpatch.StrategicMergePatch(original, data, typed)
+}
+
+// mergeMaps returns copy of dst with values of src.
+func mergeMaps(dst, src map[string]string) map[string]string {
+	out := make(map[string]string, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+// manifestsDecode decodes YAML documents into resources, empty documents are skipped.
+func manifestsDecode(in io.Reader) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := k8syaml.NewYAMLOrJSONDecoder(in, 4096)
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		objs = append(objs, &unstructured.Unstructured{Object: doc})
+	}
+}
+
+// manifestsEncode encodes resources into YAML documents.
+func manifestsEncode(objs []*unstructured.Unstructured) (*bytes.Buffer, error) {
+	out := &bytes.Buffer{}
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(data)
+	}
+	return out, nil
+}
+
+// postRendererArgs returns helm arguments which run helmctl as post-renderer of release.
+func (sc *ShellClient) postRendererArgs(r *config.Release) []string {
+	if r.PostRender == nil {
+		return nil
+	}
+
+	// post render contains only strings, so it is always encoded
+	definition, _ := json.Marshal(r.PostRender)
+	return []string{
+		"--post-renderer", sc.executable(),
+		"--post-renderer-args", PostRenderCommand,
+		"--post-renderer-args", string(definition),
+	}
+}
+
+// executable returns path of helmctl executable.
+func (sc *ShellClient) executable() string {
+	if sc.opts.Executable != "" {
+		return sc.opts.Executable
+	}
+	if path, err := os.Executable(); err == nil {
+		return path
+	}
+	return "helmctl"
+}
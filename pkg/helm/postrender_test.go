@@ -0,0 +1,170 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testDeployment = `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:1.0.0
+        - name: sidecar
+          image: sidecar:1.0.0
+`
+	testMonitor = `---
+# Source: app/templates/monitor.yaml
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: app
+spec:
+  endpoints:
+    - port: http
+`
+	testManifests = testDeployment + testMonitor
+)
+
+func TestPostRenderer(t *testing.T) {
+	path, err := filepath.Abs("testdata/patches/tolerations.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := NewPostRenderer(&config.PostRender{
+		Labels:      map[string]string{"team": "platform"},
+		Annotations: map[string]string{"owner": "helmctl"},
+		Patches: []*config.Patch{
+			{Path: path},
+			{
+				Type:   config.PatchJSON6902,
+				Patch:  `[{"op": "add", "path": "/spec/endpoints/0/interval", "value": "30s"}]`,
+				Target: &config.PatchTarget{Kind: "ServiceMonitor"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := pr.Run(bytes.NewBufferString(testManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := out.String()
+	objs, err := manifestsDecode(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 resources, got %d:\n%s", len(objs), out)
+	}
+	for _, obj := range objs {
+		if obj.GetLabels()["team"] != "platform" || obj.GetAnnotations()["owner"] != "helmctl" {
+			t.Errorf("%s has no common labels and annotations: %v %v", obj.GetKind(), obj.GetLabels(), obj.GetAnnotations())
+		}
+	}
+	if objs[0].GetLabels()["app"] != "app" {
+		t.Errorf("labels of resource were replaced: %v", objs[0].GetLabels())
+	}
+
+	for _, s := range []string{
+		// containers are merged by name
+		"image: app:1.0.0", "memory: 128Mi", "image: sidecar:1.0.0",
+		"key: dedicated",
+		"interval: 30s",
+	} {
+		if !strings.Contains(manifest, s) {
+			t.Errorf("manifests have no %q:\n%s", s, manifest)
+		}
+	}
+
+	pr, err = NewPostRenderer(&config.PostRender{
+		Patches: []*config.Patch{{Patch: "kind: Deployment\nmetadata:\n  name: unknown\n"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pr.Run(bytes.NewBufferString(testManifests)); err == nil {
+		t.Error("expected error of patch matching no resources")
+	}
+}
+
+func TestPostRendererDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"deployment.yaml": testDeployment, "monitor.yaml": testMonitor}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pr, err := NewPostRenderer(&config.PostRender{
+		Labels: map[string]string{"team": "platform"},
+		Patches: []*config.Patch{{
+			Type:   config.PatchJSON6902,
+			Patch:  "- op: replace\n  path: /spec/endpoints/0/port\n  value: metrics\n",
+			Target: &config.PatchTarget{Kind: "ServiceMonitor", Name: "app"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pr.RunDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, s := range map[string]string{"deployment.yaml": "team: platform", "monitor.yaml": "port: metrics"} {
+		out, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), s) {
+			t.Errorf("%s has no %q:\n%s", name, s, out)
+		}
+	}
+}
+
+func TestHelmInstallPostRender(t *testing.T) {
+	runner := &fakeRunner{}
+	h := newTestClient(t, "testdata/helmctl-postrender.yaml", runner, func(opts *ShellClientOptions) {
+		opts.SkipRepositories = true
+		opts.Executable = "/usr/bin/helmctl"
+	})
+
+	in := &InstallOptions{
+		Release:          "app",
+		Target:           "development",
+		TargetType:       config.TargetEnvironments,
+		KubernetesClient: fake.NewSimpleClientset(),
+	}
+	if err := h.Install(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	// patch file is read by helmctl started in the same directory
+	assertArgv(t, runner.argv(), []string{
+		"helm upgrade -i app --namespace apps " + testLabels +
+			" --post-renderer /usr/bin/helmctl --post-renderer-args post-render" +
+			` --post-renderer-args {"labels":{"team":"platform"},"patches":[{"path":"testdata/patches/tolerations.yaml"}]} ./charts/app`,
+	})
+}
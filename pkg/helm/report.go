@@ -0,0 +1,263 @@
+package helm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// Define report formats
+const (
+	ReportJSON  = "json"
+	ReportJUnit = "junit"
+)
+
+// redacted replaces secrets in reported helm arguments.
+const redacted = "*****"
+
+// secretKey matches keys of values which are redacted in report.
+var secretKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|key|credential)`)
+
+// Report contains results of install run.
+type Report struct {
+	Target     string            `json:"target"`
+	TargetType config.TargetType `json:"targetType"`
+	Diff       bool              `json:"diff"`
+	DryRun     bool              `json:"dryRun"`
+	Started    time.Time         `json:"started"`
+	// Duration of run in seconds.
+	Duration float64          `json:"duration"`
+	Releases []*ReleaseReport `json:"releases"`
+
+	mu sync.Mutex
+}
+
+// ReleaseReport contains result of release installing. Durations are in seconds.
+type ReleaseReport struct {
+	Name          string            `json:"name"`
+	Namespace     string            `json:"namespace"`
+	Target        string            `json:"target"`
+	TargetType    config.TargetType `json:"targetType"`
+	Chart         string            `json:"chart"`
+	Version       string            `json:"version,omitempty"`
+	HelmArgs      []string          `json:"helmArgs,omitempty"`
+	BeforeScripts float64           `json:"beforeScripts"`
+	Helm          float64           `json:"helm"`
+	AfterScripts  float64           `json:"afterScripts"`
+	Outcome       string            `json:"outcome"`
+	Error         string            `json:"error,omitempty"`
+	// Changed is set by diff runs.
+	Changed *bool `json:"changed,omitempty"`
+}
+
+// NewReport creates new Report object.
+func NewReport() *Report {
+	return &Report{Started: time.Now(), Releases: []*ReleaseReport{}}
+}
+
+// release returns report of release, it is added if missing.
+// Returned report is not stored if rep is nil.
+func (rep *Report) release(r *config.Release, in *InstallOptions) *ReleaseReport {
+	if rep == nil {
+		return &ReleaseReport{}
+	}
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	for _, rr := range rep.Releases {
+		if rr.Name == r.Name {
+			return rr
+		}
+	}
+
+	rr := &ReleaseReport{
+		Name:       r.Name,
+		Namespace:  r.Namespace,
+		Target:     in.Target,
+		TargetType: in.TargetType,
+		Chart:      r.Chart,
+		Version:    r.Version,
+		Outcome:    stateSkipped.String(),
+	}
+	rep.Releases = append(rep.Releases, rr)
+	return rr
+}
+
+// finish records result of release.
+func (rr *ReleaseReport) finish(state releaseState, err error) {
+	rr.Outcome = state.String()
+	if err != nil {
+		rr.Error = err.Error()
+	}
+}
+
+// Write writes report in format.
+func (rep *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case ReportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	case ReportJUnit:
+		return rep.writeJUnit(w)
+	default:
+		return fmt.Errorf("unknown report format %s, json or junit are supported", format)
+	}
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes report in JUnit XML format, every release is a test case.
+func (rep *Report) writeJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:      fmt.Sprintf("helmctl %s %s", rep.TargetType, rep.Target),
+		Time:      rep.Duration,
+		Timestamp: rep.Started.Format(time.RFC3339),
+	}
+	if rep.Diff {
+		suite.Name += " diff"
+	}
+
+	for _, rr := range rep.Releases {
+		tc := junitCase{
+			Name:      rr.Name,
+			Classname: fmt.Sprintf("%s.%s", rr.TargetType, rr.Target),
+			Time:      rr.BeforeScripts + rr.Helm + rr.AfterScripts,
+		}
+
+		out := []string{
+			fmt.Sprintf("chart: %s %s", rr.Chart, rr.Version),
+			fmt.Sprintf("namespace: %s", rr.Namespace),
+			fmt.Sprintf("durations: before scripts %.3fs, helm %.3fs, after scripts %.3fs", rr.BeforeScripts, rr.Helm, rr.AfterScripts),
+		}
+		if len(rr.HelmArgs) > 0 {
+			out = append(out, "helm args: "+strings.Join(rr.HelmArgs, " "))
+		}
+		if rr.Changed != nil {
+			out = append(out, fmt.Sprintf("changed: %t", *rr.Changed))
+		}
+		tc.SystemOut = strings.Join(out, "\n")
+
+		switch rr.Outcome {
+		case stateFailed.String():
+			tc.Failure = &junitMessage{Message: firstLine(rr.Error), Text: rr.Error}
+			suite.Failures++
+		case stateSkipped.String():
+			tc.Skipped = &junitMessage{Message: rr.Error}
+			suite.Skipped++
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// firstLine returns first line of s.
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
+
+// redactArgs returns helm arguments with values of secret keys and
+// credentials in URLs replaced. Values of --set, --set-string, --set-file and
+// other set flags are redacted in "--set k=v", "--set=k=v" and, as passed by
+// extra arguments, "--set k=v" forms.
+func redactArgs(args []string) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = arg
+
+		if i > 0 && isSetFlag(args[i-1]) {
+			res[i] = redactSet(arg)
+			continue
+		}
+		if strings.HasPrefix(arg, "--set") {
+			if j := strings.IndexAny(arg, "= "); j > 0 && isSetFlag(arg[:j]) {
+				res[i] = arg[:j+1] + redactSet(arg[j+1:])
+				continue
+			}
+		}
+
+		if u, err := url.Parse(arg); err == nil && u.User != nil {
+			res[i] = strings.Replace(arg, u.User.String()+"@", redacted+"@", 1)
+		}
+	}
+	return res
+}
+
+// isSetFlag returns true if arg is a helm flag setting values, e.g. --set or
+// --set-string.
+func isSetFlag(arg string) bool {
+	return arg == "--set" || strings.HasPrefix(arg, "--set-") && !strings.ContainsAny(arg, "= ")
+}
+
+// redactSet returns comma-separated assignments of set flag with values of
+// secret keys replaced. Escaped commas are part of the value.
+func redactSet(value string) string {
+	parts := strings.Split(value, ",")
+	res := make([]string, 0, len(parts))
+	secret := false
+	for i, part := range parts {
+		// part continues value of previous assignment after escaped comma
+		if i > 0 && strings.HasSuffix(parts[i-1], `\`) {
+			if !secret {
+				res[len(res)-1] += "," + part
+			}
+			continue
+		}
+
+		secret = false
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 && secretKey.MatchString(kv[0]) {
+			part = kv[0] + "=" + redacted
+			secret = true
+		}
+		res = append(res, part)
+	}
+	return strings.Join(res, ",")
+}
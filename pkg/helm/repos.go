@@ -0,0 +1,209 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// ReposOptions contains arguments for Repos methods.
+type ReposOptions struct {
+	// Target limits release repositories to releases of the target,
+	// repositories of all releases are used if empty.
+	Target     string
+	TargetType config.TargetType
+}
+
+// NewReposOptions creates new ReposOptions object.
+func NewReposOptions() *ReposOptions {
+	return &ReposOptions{TargetType: config.TargetEnvironments}
+}
+
+// ReposSync adds repositories which are missing or have another URL.
+func (sc *ShellClient) ReposSync(ctx context.Context, in *ReposOptions) error {
+	repos, err := sc.reposOf(in)
+	if err != nil {
+		return err
+	}
+
+	return sc.reposSync(ctx, repos, false)
+}
+
+// ReposUpdate adds missing repositories and updates indexes of all of them.
+func (sc *ShellClient) ReposUpdate(ctx context.Context, in *ReposOptions) error {
+	repos, err := sc.reposOf(in)
+	if err != nil {
+		return err
+	}
+
+	return sc.reposSync(ctx, repos, true)
+}
+
+// ReposRemove removes repositories from helm repositories file.
+func (sc *ShellClient) ReposRemove(ctx context.Context, in *ReposOptions) error {
+	repos, err := sc.reposOf(in)
+	if err != nil {
+		return err
+	}
+
+	sc.sharedMu.Lock()
+	defer sc.sharedMu.Unlock()
+
+	present, err := sc.reposPresent(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if _, ok := present[repo.Name]; !ok {
+			sc.l.Debugf("Helm repository %s is not present", repo.Name)
+			continue
+		}
+		sc.l.Infof("Remove helm repository %s", repo.Name)
+		if err := sc.backend.repoRemove(ctx, sc, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reposOf returns repositories of config and releases of the target.
+func (sc *ShellClient) reposOf(in *ReposOptions) ([]*config.Repository, error) {
+	if in.Target == "" {
+		return sc.repositories(sc.cfg.Releases()), nil
+	}
+
+	releases, err := sc.cfg.TargetReleases(in.Target, in.TargetType)
+	if err != nil {
+		return nil, err
+	}
+	return sc.repositories(releases), nil
+}
+
+// targetReposSync syncs repositories of config and release of the target,
+// all releases of the target are used if release is "all". Indexes of present
+// repositories are updated unless SkipReposUpdate option is set.
+func (sc *ShellClient) targetReposSync(ctx context.Context, release, target string, targetType config.TargetType) error {
+	if sc.opts.SkipRepositories {
+		sc.l.Info("Skip repositories adding, because of SkipRepositories flag")
+		return nil
+	}
+
+	var releases []*config.Release
+	if release == "all" {
+		var err error
+		if releases, err = sc.cfg.TargetReleases(target, targetType); err != nil {
+			return err
+		}
+	} else {
+		r, err := sc.cfg.TargetRelease(release, target, targetType)
+		if err != nil {
+			return err
+		}
+		releases = []*config.Release{r}
+	}
+
+	return sc.reposSync(ctx, sc.repositories(releases), !sc.opts.SkipReposUpdate)
+}
+
+// repositories returns repositories of config and releases, repository
+// defined several times is returned once.
+func (sc *ShellClient) repositories(releases []*config.Release) []*config.Repository {
+	repos := []*config.Repository{}
+	idx := map[string]*config.Repository{}
+
+	add := func(repo *config.Repository) {
+		if repo == nil || repo.Name == "" {
+			return
+		}
+		if known, ok := idx[repo.Name]; ok {
+			if !sameURL(known.URL, repo.URL) {
+				sc.l.Warnf("Helm repository %s is defined with URLs %s and %s, the first one is used", repo.Name, known.URL, repo.URL)
+			}
+			return
+		}
+		idx[repo.Name] = repo
+		repos = append(repos, repo)
+	}
+
+	for _, repo := range sc.cfg.Repositories() {
+		add(repo)
+	}
+	for _, r := range releases {
+		add(r.Repository)
+	}
+	return repos
+}
+
+// reposSync adds repositories which are missing or have another URL, all
+// repositories are added again with ForceUpdate option. Indexes of present
+// repositories are updated by single call if refresh is true, indexes are
+// updated once per run.
+func (sc *ShellClient) reposSync(ctx context.Context, repos []*config.Repository, refresh bool) error {
+	if sc.opts.SkipRepositories {
+		sc.l.Info("Skip repositories adding, because of SkipRepositories flag")
+		return nil
+	}
+
+	sc.sharedMu.Lock()
+	defer sc.sharedMu.Unlock()
+
+	present, err := sc.reposPresent(ctx)
+	if err != nil {
+		return err
+	}
+
+	updates := []string{}
+	for _, repo := range repos {
+		if registry.IsOCI(repo.URL) {
+			sc.l.Infof("Log in to helm registry %s", repo.Name)
+			if err := sc.backend.repoAdd(ctx, sc, repo, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		url, ok := present[repo.Name]
+		if ok && sameURL(url, repo.URL) && !sc.opts.ForceUpdate {
+			sc.l.Debugf("Helm repository %s is present", repo.Name)
+			updates = append(updates, repo.Name)
+			continue
+		}
+
+		sc.l.Infof("Add helm repository %s", repo.Name)
+		if err := sc.backend.repoAdd(ctx, sc, repo, ok || sc.opts.ForceUpdate); err != nil {
+			return err
+		}
+	}
+
+	if refresh && len(updates) > 0 && !sc.reposUpdated {
+		sc.l.Infof("Update helm repositories %s", strings.Join(updates, ", "))
+		if err := sc.backend.repoUpdate(ctx, sc, updates); err != nil {
+			return err
+		}
+		sc.reposUpdated = true
+	}
+	return nil
+}
+
+// reposPresent returns URLs of present repositories by names.
+func (sc *ShellClient) reposPresent(ctx context.Context) (map[string]string, error) {
+	list, err := sc.backend.repoList(ctx, sc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list helm repositories, %w", err)
+	}
+
+	present := make(map[string]string, len(list))
+	for _, repo := range list {
+		present[repo.Name] = repo.URL
+	}
+	return present, nil
+}
+
+// sameURL compares repository URLs ignoring trailing slash.
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -0,0 +1,143 @@
+package helm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+const testRepoList = `[{"name":"eclipse-iot","url":"https://eclipse.org/packages/charts"},{"name":"gitlab","url":"https://gitlab.example.com"}]`
+
+func TestReposSync(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(opts *ShellClientOptions)
+		want   []string
+	}{
+		{
+			name: "missing and changed",
+			want: []string{
+				"helm repo list -o json",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+			},
+		},
+		{
+			name:   "force update",
+			modify: func(opts *ShellClientOptions) { opts.ForceUpdate = true },
+			want: []string{
+				"helm repo list -o json",
+				"helm repo add eclipse-iot https://eclipse.org/packages/charts --force-update",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := (&fakeRunner{}).on("helm repo list", testRepoList, nil)
+			h := newTestClient(t, "testdata/helmctl.yaml", runner, tt.modify)
+
+			if err := h.ReposSync(context.Background(), NewReposOptions()); err != nil {
+				t.Fatal(err)
+			}
+			assertArgv(t, runner.argv(), tt.want)
+		})
+	}
+}
+
+func TestTargetReposSync(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(opts *ShellClientOptions)
+		want   []string
+	}{
+		{
+			name: "update once",
+			want: []string{
+				"helm repo list -o json",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+				"helm repo update eclipse-iot",
+				"helm repo list -o json",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+			},
+		},
+		{
+			name:   "skip update",
+			modify: func(opts *ShellClientOptions) { opts.SkipReposUpdate = true },
+			want: []string{
+				"helm repo list -o json",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+				"helm repo list -o json",
+				"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := (&fakeRunner{}).on("helm repo list", testRepoList, nil)
+			sc := newTestClient(t, "testdata/helmctl.yaml", runner, tt.modify).(*ShellClient)
+
+			// indexes are updated by the first sync of the run only
+			for i := 0; i < 2; i++ {
+				if err := sc.targetReposSync(context.Background(), "all", "development", config.TargetEnvironments); err != nil {
+					t.Fatal(err)
+				}
+			}
+			assertArgv(t, runner.argv(), tt.want)
+		})
+	}
+}
+
+func TestReposSyncEmpty(t *testing.T) {
+	// helm fails to list repositories if there are no repositories
+	runner := (&fakeRunner{}).on("helm repo list", "", &fakeExitError{code: 1})
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, nil)
+
+	if err := h.ReposUpdate(context.Background(), NewReposOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// added repositories have fresh indexes, so nothing is updated
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add eclipse-iot https://eclipse.org/packages/charts",
+		"helm repo add gitlab https://charts.gitlab.io/",
+	})
+}
+
+func TestReposUpdate(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm repo list", testRepoList, nil)
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, nil)
+
+	if err := h.ReposUpdate(context.Background(), NewReposOptions()); err != nil {
+		t.Fatal(err)
+	}
+	// indexes are updated only by ReposUpdate itself
+	if err := h.ReposSync(context.Background(), NewReposOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+		"helm repo update eclipse-iot",
+		"helm repo list -o json",
+		"helm repo add gitlab https://charts.gitlab.io/ --force-update",
+	})
+}
+
+func TestReposRemove(t *testing.T) {
+	runner := (&fakeRunner{}).on("helm repo list", `[{"name":"gitlab","url":"https://charts.gitlab.io"}]`, nil)
+	h := newTestClient(t, "testdata/helmctl.yaml", runner, nil)
+
+	if err := h.ReposRemove(context.Background(), NewReposOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArgv(t, runner.argv(), []string{
+		"helm repo list -o json",
+		"helm repo remove gitlab",
+	})
+}
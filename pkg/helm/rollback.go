@@ -0,0 +1,136 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	helmctlKubernetes "github.com/sprokhorov/helmctl/pkg/kubernetes"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RollbackOptions contains arguments for Rollback method.
+type RollbackOptions struct {
+	Release    string
+	Target     string
+	TargetType config.TargetType
+	// Revision to roll back to, previous revision is used if zero.
+	Revision int
+	// UninstallNew confirms uninstalling of releases installed for the first
+	// time by the run, rollback of all releases fails without it.
+	UninstallNew bool
+}
+
+// NewRollbackOptions creates new RollbackOptions object.
+func NewRollbackOptions() *RollbackOptions {
+	return &RollbackOptions{TargetType: config.TargetEnvironments}
+}
+
+// Rollback rolls back release or releases touched by the last helmctl run.
+// Releases installed for the first time by the run are uninstalled.
+func (sc *ShellClient) Rollback(ctx context.Context, in *RollbackOptions) error {
+	if in.Release != "all" {
+		r, err := sc.cfg.TargetRelease(in.Release, in.Target, in.TargetType)
+		if err != nil {
+			return err
+		}
+		return sc.releaseRollback(ctx, r.Name, r.Namespace, in.Revision)
+	}
+
+	if in.Revision != 0 {
+		return errors.New("revision is allowed only for single release")
+	}
+
+	if sc.opts.HistoryFile == "" {
+		return errors.New("history file is not defined")
+	}
+	history, err := LoadHistory(sc.opts.HistoryFile)
+	if err != nil {
+		return fmt.Errorf("cannot load history, %v", err)
+	}
+
+	run := history.LastRun(in.Target, in.TargetType)
+	if run == nil {
+		return fmt.Errorf("no recorded helmctl run for %s %s", in.TargetType, in.Target)
+	}
+
+	// releases are checked before any change, uninstalling is shown by dry run
+	installed := []string{}
+	for _, r := range run.Releases {
+		if r.PreviousRevision == 0 {
+			installed = append(installed, r.Name)
+		}
+	}
+	if len(installed) > 0 && !in.UninstallNew && !sc.opts.DryRun {
+		return fmt.Errorf("releases %s were installed for the first time by the run, use --uninstall-new to uninstall them", strings.Join(installed, ", "))
+	}
+	sc.l.Infof("Roll back releases installed at %s", run.Time.Format("2006-01-02 15:04:05"))
+
+	// roll back in reverse order of installation
+	for i := len(run.Releases) - 1; i >= 0; i-- {
+		r := run.Releases[i]
+		if r.PreviousRevision == 0 {
+			sc.l.Infof("Uninstall helm release %s, it was not installed before the run", r.Name)
+			if err := sc.helmUninstall(ctx, r.Name, r.Namespace); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sc.releaseRollback(ctx, r.Name, r.Namespace, r.PreviousRevision); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseRollback rolls back helm release to revision.
+func (sc *ShellClient) releaseRollback(ctx context.Context, name, namespace string, revision int) error {
+	sc.l.Infof("Roll back helm release %s", name)
+
+	out, err := sc.backend.rollback(ctx, sc, name, namespace, revision)
+	if err != nil {
+		return err
+	}
+	sc.l.Info(strings.ReplaceAll(out, "\n", "\n\t"))
+
+	return nil
+}
+
+// historyRecord adds release with its current revision to the run.
+func (sc *ShellClient) historyRecord(ctx context.Context, run *Run, r *config.Release, client kubernetes.Interface) error {
+	deployed, err := helmctlKubernetes.LatestHelmRelease(ctx, client, r.Namespace, r.Name)
+	if err != nil {
+		return err
+	}
+
+	rr := &RunRelease{Name: r.Name, Namespace: r.Namespace}
+	if deployed != nil {
+		rr.PreviousRevision = deployed.Version
+	}
+	run.mu.Lock()
+	run.Releases = append(run.Releases, rr)
+	run.mu.Unlock()
+
+	return nil
+}
+
+// historySave records the run to history file.
+func (sc *ShellClient) historySave(run *Run) {
+	if len(run.Releases) == 0 {
+		return
+	}
+
+	history, err := LoadHistory(sc.opts.HistoryFile)
+	if err != nil {
+		sc.l.Warnf("Cannot load history, %v", err)
+		return
+	}
+
+	history.SetRun(run)
+	if err := history.Save(sc.opts.HistoryFile); err != nil {
+		sc.l.Warnf("Cannot save history, %v", err)
+	}
+}
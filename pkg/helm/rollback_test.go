@@ -0,0 +1,90 @@
+package helm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+func TestRollbackAll(t *testing.T) {
+	tests := []struct {
+		name         string
+		uninstallNew bool
+		dryRun       bool
+		want         []string
+		err          string
+	}{
+		{
+			name:         "uninstall new",
+			uninstallNew: true,
+			want: []string{
+				"helm uninstall app --namespace apps",
+				"helm rollback db 2 --namespace db",
+			},
+		},
+		{
+			name: "not confirmed",
+			err:  "releases app were installed for the first time by the run",
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			want: []string{
+				"helm uninstall app --namespace apps --dry-run",
+				"helm rollback db 2 --namespace db --dry-run",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultHistoryFile)
+			history, err := LoadHistory(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// app is installed by the run after db was upgraded
+			history.SetRun(&Run{
+				Target:     "development",
+				TargetType: config.TargetEnvironments,
+				Time:       time.Now(),
+				Releases: []*RunRelease{
+					{Name: "db", Namespace: "db", PreviousRevision: 2},
+					{Name: "app", Namespace: "apps"},
+				},
+			})
+			if err := history.Save(path); err != nil {
+				t.Fatal(err)
+			}
+
+			runner := &fakeRunner{}
+			h := newTestClient(t, "testdata/helmctl.yaml", runner, func(opts *ShellClientOptions) {
+				opts.HistoryFile = path
+				opts.DryRun = tt.dryRun
+			})
+
+			in := NewRollbackOptions()
+			in.Release = "all"
+			in.Target = "development"
+			in.UninstallNew = tt.uninstallNew
+			err = h.Rollback(context.Background(), in)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				if len(runner.commands) != 0 {
+					t.Errorf("expected no commands, got %v", runner.argv())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertArgv(t, runner.argv(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command is an external command executed by Runner.
+type Command struct {
+	Path string
+	Args []string
+	// Env is added to environment of helmctl process.
+	Env []string
+	// Dir is working directory, current directory is used if empty.
+	Dir string
+	// StdoutOnly excludes standard error from output.
+	StdoutOnly bool
+	// Stdin is passed to standard input, it is used for secrets which
+	// must not be visible in process list.
+	Stdin string
+	// Output receives output while command is running, output is
+	// returned by Run as well.
+	Output io.Writer
+}
+
+// Runner executes external commands: helm binary and scripts.
+type Runner interface {
+	// Run executes command and returns its output. Error returned for
+	// a command finished with non-zero code has ExitCode method.
+	// Command is killed when ctx is done.
+	Run(ctx context.Context, c *Command) ([]byte, error)
+}
+
+// ExecRunner implements Runner with os/exec.
+type ExecRunner struct{}
+
+// Run executes command with os/exec. Command is started in its own process
+// group, so interrupt from terminal is handled by helmctl only.
+func (ExecRunner) Run(ctx context.Context, c *Command) ([]byte, error) {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+
+	var out bytes.Buffer
+	var w io.Writer = &out
+	if c.Output != nil {
+		w = io.MultiWriter(&out, c.Output)
+	}
+	cmd.Stdout = w
+	if !c.StdoutOnly {
+		cmd.Stderr = w
+	}
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return out.Bytes(), err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		return out.Bytes(), ctx.Err()
+	}
+}
+
+// exitCode returns exit code of finished command or -1 if command was not finished.
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,131 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRunner records executed commands and returns scripted output.
+type fakeRunner struct {
+	mu        sync.Mutex
+	commands  []*Command
+	responses []*fakeResponse
+	// hook is called for every command before output is returned.
+	hook func(ctx context.Context, c *Command) error
+}
+
+// fakeResponse is output returned for commands with argv prefix.
+type fakeResponse struct {
+	prefix string
+	out    string
+	err    error
+}
+
+// fakeExitError is error of command finished with non-zero code.
+type fakeExitError struct {
+	code int
+}
+
+func (e *fakeExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *fakeExitError) ExitCode() int {
+	return e.code
+}
+
+// on scripts output of commands which argv starts with prefix,
+// the first matching response is used.
+func (f *fakeRunner) on(prefix, out string, err error) *fakeRunner {
+	f.responses = append(f.responses, &fakeResponse{prefix: prefix, out: out, err: err})
+	return f
+}
+
+// Run records command and returns scripted output.
+func (f *fakeRunner) Run(ctx context.Context, c *Command) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.commands = append(f.commands, c)
+
+	if f.hook != nil {
+		if err := f.hook(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	argv := argvString(c)
+	for _, r := range f.responses {
+		if strings.HasPrefix(argv, r.prefix) {
+			return []byte(r.out), r.err
+		}
+	}
+	return nil, nil
+}
+
+// argv returns executed commands as strings.
+func (f *fakeRunner) argv() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	argv := make([]string, 0, len(f.commands))
+	for _, c := range f.commands {
+		argv = append(argv, argvString(c))
+	}
+	return argv
+}
+
+func argvString(c *Command) string {
+	return strings.Join(append([]string{c.Path}, c.Args...), " ")
+}
+
+// assertArgv compares executed commands with expected ones.
+func assertArgv(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d commands, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("command %d:\nexpected %s\ngot      %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	dir := t.TempDir()
+
+	out, err := ExecRunner{}.Run(context.Background(), &Command{
+		Path: "sh",
+		Args: []string{"-c", `echo "$HELMCTL_TEST $(pwd)"`},
+		Env:  []string{"HELMCTL_TEST=value"},
+		Dir:  dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "value "+dir {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	out, err = ExecRunner{}.Run(context.Background(), &Command{Path: "sh", Args: []string{"-c", "echo out; echo err >&2"}, StdoutOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "out\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	_, err = ExecRunner{}.Run(context.Background(), &Command{Path: "sh", Args: []string{"-c", "exit 2"}})
+	if code := exitCode(fmt.Errorf("wrapped, %w", err)); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if code := exitCode(errors.New("not started")); code != -1 {
+		t.Errorf("expected exit code -1, got %d", code)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package helm
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts command in a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills command and processes started by it.
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
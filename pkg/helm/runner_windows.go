@@ -0,0 +1,15 @@
+//go:build windows
+
+package helm
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing, process groups are not supported.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills command.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,161 @@
+package helm
+
+import (
+	"fmt"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// releaseState is a state of release processed by scheduler.
+type releaseState int
+
+// Define release states
+const (
+	statePending releaseState = iota
+	stateRunning
+	stateSucceeded
+	stateFailed
+	stateSkipped
+)
+
+// String returns name of release state.
+func (s releaseState) String() string {
+	switch s {
+	case statePending:
+		return "pending"
+	case stateRunning:
+		return "running"
+	case stateSucceeded:
+		return "succeeded"
+	case stateFailed:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+// stateOf returns state of finished release.
+func stateOf(err error) releaseState {
+	if err != nil {
+		return stateFailed
+	}
+	return stateSucceeded
+}
+
+// releaseResult contains result of release processing.
+type releaseResult struct {
+	release *config.Release
+	state   releaseState
+	err     error
+}
+
+// schedule runs fn for releases respecting release needs. Up to parallel
+// releases are processed at the same time. A release is started when all
+// needed releases from the list succeeded. After the first failure new
+// releases are not started, not started releases are reported as skipped.
+// With keepGoing only releases which need failed releases are skipped.
+// New releases are not started after stop is closed as well.
+// Results are returned in order of releases.
+func schedule(stop <-chan struct{}, releases []*config.Release, parallel int, keepGoing bool, fn func(r *config.Release) error) []*releaseResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]*releaseResult, len(releases))
+	index := make(map[string]int, len(releases))
+	for i, r := range releases {
+		results[i] = &releaseResult{release: r, state: statePending}
+		index[r.Name] = i
+	}
+
+	// ready checks that all needed releases from the list succeeded
+	ready := func(r *config.Release) bool {
+		for _, need := range r.Needs {
+			if i, listed := index[need]; listed && results[i].state != stateSucceeded {
+				return false
+			}
+		}
+		return true
+	}
+
+	// skipBlocked skips pending releases which need failed or skipped releases
+	skipBlocked := func() {
+		for changed := true; changed; {
+			changed = false
+			for _, res := range results {
+				if res.state != statePending {
+					continue
+				}
+				for _, need := range res.release.Needs {
+					i, listed := index[need]
+					if !listed || (results[i].state != stateFailed && results[i].state != stateSkipped) {
+						continue
+					}
+					res.state = stateSkipped
+					res.err = fmt.Errorf("needed release %s was %s", need, results[i].state)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	type finished struct {
+		idx int
+		err error
+	}
+	done := make(chan finished)
+	running := 0
+	failed := false
+
+	for {
+		if keepGoing {
+			skipBlocked()
+		}
+		for i, res := range results {
+			if (failed && !keepGoing) || stopped(stop) || running >= parallel {
+				break
+			}
+			if res.state != statePending || !ready(res.release) {
+				continue
+			}
+			res.state = stateRunning
+			running++
+			go func(i int, r *config.Release) {
+				done <- finished{i, fn(r)}
+			}(i, res.release)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		f := <-done
+		running--
+		if f.err != nil {
+			results[f.idx].state = stateFailed
+			results[f.idx].err = f.err
+			failed = true
+			continue
+		}
+		results[f.idx].state = stateSucceeded
+	}
+
+	for _, res := range results {
+		if res.state == statePending {
+			res.state = stateSkipped
+		}
+	}
+
+	return results
+}
+
+// stopped checks if stop is closed.
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package helm
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+func TestSchedule(t *testing.T) {
+	releases := []*config.Release{
+		{Name: "cert-manager"},
+		{Name: "ingress", Needs: []string{"cert-manager"}},
+		{Name: "app", Needs: []string{"ingress", "not-in-target"}},
+		{Name: "monitoring"},
+		{Name: "logging"},
+	}
+
+	var mu sync.Mutex
+	finished := map[string]bool{}
+	running, maxRunning := 0, 0
+
+	results := schedule(nil, releases, 2, false, func(r *config.Release) error {
+		mu.Lock()
+		for _, need := range r.Needs {
+			if need != "not-in-target" && !finished[need] {
+				t.Errorf("release %s started before %s", r.Name, need)
+			}
+		}
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		finished[r.Name] = true
+		mu.Unlock()
+		return nil
+	})
+
+	if maxRunning != 2 {
+		t.Errorf("expected 2 releases running at the same time, got %d", maxRunning)
+	}
+	for i, res := range results {
+		if res.release != releases[i] || res.state != stateSucceeded {
+			t.Errorf("unexpected result of %s: %+v", releases[i].Name, res)
+		}
+	}
+}
+
+func TestScheduleFailure(t *testing.T) {
+	releases := []*config.Release{
+		{Name: "cert-manager"},
+		{Name: "ingress", Needs: []string{"cert-manager"}},
+		{Name: "monitoring"},
+	}
+
+	results := schedule(nil, releases, 1, false, func(r *config.Release) error {
+		if r.Name == "cert-manager" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	expected := []releaseState{stateFailed, stateSkipped, stateSkipped}
+	for i, res := range results {
+		if res.state != expected[i] {
+			t.Errorf("%s: expected state %s, got %s", res.release.Name, expected[i], res.state)
+		}
+	}
+	if results[0].err == nil {
+		t.Error("error of failed release is not reported")
+	}
+}
+
+func TestScheduleStop(t *testing.T) {
+	releases := []*config.Release{
+		{Name: "cert-manager"},
+		{Name: "ingress"},
+		{Name: "monitoring"},
+	}
+
+	stop := make(chan struct{})
+	results := schedule(stop, releases, 1, false, func(r *config.Release) error {
+		// running release is finished after stop
+		close(stop)
+		return nil
+	})
+
+	expected := []releaseState{stateSucceeded, stateSkipped, stateSkipped}
+	for i, res := range results {
+		if res.state != expected[i] {
+			t.Errorf("%s: expected state %s, got %s", res.release.Name, expected[i], res.state)
+		}
+	}
+}
+
+func TestScheduleKeepGoing(t *testing.T) {
+	releases := []*config.Release{
+		{Name: "cert-manager"},
+		{Name: "ingress", Needs: []string{"cert-manager"}},
+		{Name: "monitoring"},
+		{Name: "logging", Needs: []string{"monitoring"}},
+		{Name: "app", Needs: []string{"ingress"}},
+	}
+
+	results := schedule(nil, releases, 1, true, func(r *config.Release) error {
+		if r.Name == "cert-manager" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	expected := []releaseState{stateFailed, stateSkipped, stateSucceeded, stateSucceeded, stateSkipped}
+	for i, res := range results {
+		if res.state != expected[i] {
+			t.Errorf("%s: expected state %s, got %s", res.release.Name, expected[i], res.state)
+		}
+	}
+	if results[1].err == nil || results[4].err == nil {
+		t.Error("reason of skipped releases is not reported")
+	}
+}
@@ -0,0 +1,151 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// scriptsExecute executes release scripts with release context in environment.
+// Output of scripts is written to logger output while they are running.
+func (sc *ShellClient) scriptsExecute(ctx context.Context, r *config.Release, target string, targetType config.TargetType, scripts []*config.Script) error {
+	return sc.scriptsRun(ctx, scripts, sc.scriptEnv(r, target, targetType), r.Name)
+}
+
+// failureScriptsExecute executes release scripts with text of install error in environment.
+func (sc *ShellClient) failureScriptsExecute(ctx context.Context, r *config.Release, target string, targetType config.TargetType, scripts []*config.Script, failure error) error {
+	env := append(sc.scriptEnv(r, target, targetType), "HELMCTL_ERROR="+failure.Error())
+	return sc.scriptsRun(ctx, scripts, env, r.Name)
+}
+
+// hooksExecute executes target hook scripts, text of failure is passed
+// in environment if it is not nil.
+func (sc *ShellClient) hooksExecute(ctx context.Context, target string, targetType config.TargetType, scripts []*config.Script, failure error) error {
+	env := []string{
+		"HELMCTL_TARGET=" + target,
+		"HELMCTL_TARGET_TYPE=" + string(targetType),
+		"HELMCTL_DRY_RUN=" + strconv.FormatBool(sc.opts.DryRun),
+	}
+	if failure != nil {
+		env = append(env, "HELMCTL_ERROR="+failure.Error())
+	}
+	return sc.scriptsRun(ctx, scripts, env, target)
+}
+
+// scriptsRun executes scripts one by one unless scripts are disabled.
+func (sc *ShellClient) scriptsRun(ctx context.Context, scripts []*config.Script, env []string, name string) error {
+	for _, script := range scripts {
+		switch {
+		case sc.opts.DryRun && !sc.opts.WithScripts:
+			sc.l.Infof("Skip %s script running, because of DryRun flag", script)
+			continue
+		case sc.opts.SkipScripts:
+			sc.l.Infof("Skip %s script running, because of SkipScripts flag", script)
+			continue
+		}
+
+		sc.l.Infof("Run script %s", script)
+		if err := sc.scriptExecute(ctx, script, env, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scriptExecute executes script with environment, output lines are prefixed with name.
+func (sc *ShellClient) scriptExecute(ctx context.Context, script *config.Script, env []string, name string) error {
+	if timeout := script.GetTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	c := &Command{Env: env, Dir: script.Dir}
+	if script.Run != "" {
+		// the first argument after command is $0
+		c.Path = "sh"
+		c.Args = append([]string{"-c", script.Run, "sh"}, script.Args...)
+	} else {
+		path, err := filepath.Abs(script.Path)
+		if err != nil {
+			return err
+		}
+		c.Path = path
+		c.Args = script.Args
+	}
+
+	out := newPrefixWriter(sc.l.Out, fmt.Sprintf("[%s] ", name))
+	c.Output = out
+	_, err := sc.runner.Run(ctx, c)
+	out.Flush()
+	if err != nil {
+		return fmt.Errorf("script %s failed, %w", script, err)
+	}
+	return nil
+}
+
+// scriptEnv returns environment of release scripts.
+func (sc *ShellClient) scriptEnv(r *config.Release, target string, targetType config.TargetType) []string {
+	return []string{
+		"HELMCTL_RELEASE=" + r.Name,
+		"HELMCTL_NAMESPACE=" + r.Namespace,
+		"HELMCTL_TARGET=" + target,
+		"HELMCTL_TARGET_TYPE=" + string(targetType),
+		"HELMCTL_CHART=" + r.Chart,
+		"HELMCTL_VERSION=" + r.Version,
+		"HELMCTL_DRY_RUN=" + strconv.FormatBool(sc.opts.DryRun),
+	}
+}
+
+// outputMu serializes output of scripts running at the same time.
+var outputMu sync.Mutex
+
+// prefixWriter writes complete lines with prefix, so output of releases
+// installed in parallel is not mixed within a line.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+// newPrefixWriter creates new prefixWriter object.
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: prefix}
+}
+
+// Write writes complete lines of p, the rest is buffered.
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(pw.buf.Bytes(), '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+		line := pw.buf.Next(idx + 1)
+		if err := pw.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes buffered incomplete line.
+func (pw *prefixWriter) Flush() {
+	if pw.buf.Len() > 0 {
+		_ = pw.writeLine(append(pw.buf.Bytes(), '\n'))
+		pw.buf.Reset()
+	}
+}
+
+func (pw *prefixWriter) writeLine(line []byte) error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	_, err := pw.w.Write(append([]byte(pw.prefix), line...))
+	return err
+}
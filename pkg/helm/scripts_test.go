@@ -0,0 +1,51 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+func TestScriptsExecute(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.Out = &out
+
+	sc := &ShellClient{
+		l:      log,
+		opts:   &ShellClientOptions{DryRun: true, WithScripts: true},
+		runner: ExecRunner{},
+	}
+	r := &config.Release{Name: "app", Namespace: "apps", Chart: "repo/app", Version: "1.0.0"}
+	dir := t.TempDir()
+
+	scripts := []*config.Script{
+		{Run: `echo "$HELMCTL_RELEASE $HELMCTL_NAMESPACE $HELMCTL_TARGET $HELMCTL_TARGET_TYPE $HELMCTL_CHART $HELMCTL_VERSION $HELMCTL_DRY_RUN"`},
+		{Run: "echo $1 $2; printf partial", Args: []string{"one", "two"}},
+		{Run: "pwd", Dir: dir},
+	}
+	if err := sc.scriptsExecute(context.Background(), r, "development", config.TargetEnvironments, scripts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"[app] app apps development environments repo/app 1.0.0 true\n",
+		"[app] one two\n",
+		"[app] partial\n",
+		"[app] " + dir + "\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output has no line %q:\n%s", line, out.String())
+		}
+	}
+
+	err := sc.scriptsExecute(context.Background(), r, "development", config.TargetEnvironments, []*config.Script{{Run: "sleep 5", Timeout: "10ms"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
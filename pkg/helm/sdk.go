@@ -0,0 +1,596 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Define helm client backends
+const (
+	BackendShell = "shell"
+	BackendSDK   = "sdk"
+)
+
+// NewClient returns Helm implementation for backend.
+func NewClient(backend string, cfg config.Config, opts *ShellClientOptions) (Helm, error) {
+	switch backend {
+	case BackendShell, "":
+		return NewShellClient(cfg, opts)
+	case BackendSDK:
+		return NewSDKClient(cfg, opts)
+	default:
+		return nil, fmt.Errorf("unknown backend %s, shell or sdk are supported", backend)
+	}
+}
+
+// SDKClient implements Helm with Helm Go SDK, helm binary is not required.
+// Releases processing is the same as in ShellClient, only helm operations
+// are executed in-process.
+type SDKClient struct {
+	*ShellClient
+}
+
+// NewSDKClient returns new SDKClient.
+func NewSDKClient(cfg config.Config, opts *ShellClientOptions) (Helm, error) {
+	h, err := NewShellClient(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := h.(*ShellClient)
+	sc.backend = &sdkBackend{settings: cli.New()}
+
+	return &SDKClient{ShellClient: sc}, nil
+}
+
+// sdkBackend executes helm operations with Helm Go SDK.
+type sdkBackend struct {
+	// settings contains helm environment: kubeconfig, repositories and cache paths.
+	settings *cli.EnvSettings
+	// mu serializes access to helm repositories file.
+	mu sync.Mutex
+}
+
+// actionConfig returns helm action configuration for namespace.
+func (b *sdkBackend) actionConfig(sc *ShellClient, namespace string) (*action.Configuration, error) {
+	cfg := &action.Configuration{}
+	getter := kube.GetConfig(b.settings.KubeConfig, b.settings.KubeContext, namespace)
+	if err := cfg.Init(getter, namespace, os.Getenv("HELM_DRIVER"), sc.l.Debugf); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// registryClient returns client for OCI registries.
+func (b *sdkBackend) registryClient() (*registry.Client, error) {
+	return registry.NewClient(registry.ClientOptCredentialsFile(b.settings.RegistryConfig))
+}
+
+// chartPathOptions returns chart options and chart name to locate release chart.
+// Charts prefixed with a repository defined in config are located by repository URL,
+// other charts are located with helm repositories file.
+func (b *sdkBackend) chartPathOptions(sc *ShellClient, r *config.Release) (action.ChartPathOptions, string) {
+	// verified archive of locked chart is local
+	if chart, version := sc.chartRef(r); chart != r.Chart {
+		return action.ChartPathOptions{Version: version}, chart
+	}
+	opts := action.ChartPathOptions{Version: r.Version}
+
+	repos := append([]*config.Repository{r.Repository}, sc.cfg.Repositories()...)
+	for _, repo := range repos {
+		// OCI charts are referenced by registry URL, credentials are stored by registry login
+		if registry.IsOCI(r.Chart) && registry.IsOCI(repo.URL) && strings.HasPrefix(r.Chart, strings.TrimSuffix(repo.URL, "/")+"/") {
+			repoTLSOptions(&opts, repo)
+			return opts, r.Chart
+		}
+		if repo.Name == "" || !strings.HasPrefix(r.Chart, repo.Name+"/") {
+			continue
+		}
+		opts.RepoURL = repo.URL
+		opts.Username = repo.User
+		opts.Password = repo.Password
+		opts.PassCredentialsAll = repo.PassCredentials
+		repoTLSOptions(&opts, repo)
+		return opts, strings.TrimPrefix(r.Chart, repo.Name+"/")
+	}
+
+	return opts, r.Chart
+}
+
+// repoTLSOptions sets TLS options of repository.
+func repoTLSOptions(opts *action.ChartPathOptions, repo *config.Repository) {
+	opts.CaFile = repo.CAFile
+	opts.CertFile = repo.CertFile
+	opts.KeyFile = repo.KeyFile
+	opts.InsecureSkipTLSverify = repo.InsecureSkipTLSVerify
+}
+
+// loadChart locates and loads release chart.
+func (b *sdkBackend) loadChart(sc *ShellClient, r *config.Release, opts *action.ChartPathOptions, name string) (*chart.Chart, error) {
+	path, err := opts.LocateChart(name, b.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.Load(path)
+}
+
+// values returns merged release values.
+func (b *sdkBackend) values(sc *ShellClient, r *config.Release) (map[string]interface{}, error) {
+	opts := &values.Options{}
+	for _, vf := range r.ValueFiles {
+		opts.ValueFiles = append(opts.ValueFiles, sc.valueFilePath(vf))
+	}
+	for _, v := range r.Values {
+		if v.Type == "string" {
+			opts.StringValues = append(opts.StringValues, v.GetKeyValuePair())
+			continue
+		}
+		opts.Values = append(opts.Values, v.GetKeyValuePair())
+	}
+
+	return opts.MergeValues(getter.All(b.settings))
+}
+
+// render returns release installed or upgraded in dry-run mode.
+func (b *sdkBackend) render(ctx context.Context, sc *ShellClient, r *config.Release, in *InstallOptions, dryRun bool) (*release.Release, error) {
+	cfg, err := b.actionConfig(sc, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := b.registryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	vals, err := b.values(sc, r)
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := b.exists(ctx, sc, r.Name, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	if in != nil {
+		labels = releaseLabels(in.Target, in.TargetType)
+	}
+
+	if len(r.ExtraArgs) > 0 {
+		sc.l.Warnf("Extra arguments of release %s are not supported by helm SDK and ignored", r.Name)
+	}
+
+	var pr *PostRenderer
+	if r.PostRender != nil {
+		if pr, err = NewPostRenderer(r.PostRender); err != nil {
+			return nil, err
+		}
+	}
+
+	if !installed {
+		install := action.NewInstall(cfg)
+		install.ReleaseName = r.Name
+		install.Namespace = r.Namespace
+		install.Atomic = *r.Atomic
+		install.Wait = *r.Wait
+		install.WaitForJobs = *r.WaitForJobs
+		install.Timeout = releaseTimeout(r)
+		install.Force = *r.Force
+		install.SkipCRDs = *r.SkipCRDs
+		install.DisableHooks = *r.DisableHooks
+		install.Description = r.Description
+		if pr != nil {
+			install.PostRenderer = pr
+		}
+		install.DryRun = dryRun
+		install.Labels = labels
+
+		opts, name := b.chartPathOptions(sc, r)
+		install.ChartPathOptions = opts
+		install.SetRegistryClient(rc)
+		ch, err := b.loadChart(sc, r, &install.ChartPathOptions, name)
+		if err != nil {
+			return nil, err
+		}
+		return install.RunWithContext(ctx, ch, vals)
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = r.Namespace
+	upgrade.Atomic = *r.Atomic
+	upgrade.Wait = *r.Wait
+	upgrade.WaitForJobs = *r.WaitForJobs
+	upgrade.Timeout = releaseTimeout(r)
+	upgrade.Force = *r.Force
+	upgrade.CleanupOnFail = *r.CleanupOnFail
+	upgrade.SkipCRDs = *r.SkipCRDs
+	upgrade.DisableHooks = *r.DisableHooks
+	upgrade.ResetValues = *r.ResetValues
+	upgrade.ReuseValues = *r.ReuseValues
+	upgrade.Description = r.Description
+	if pr != nil {
+		upgrade.PostRenderer = pr
+	}
+	if r.MaxHistory != nil {
+		upgrade.MaxHistory = *r.MaxHistory
+	}
+	upgrade.DryRun = dryRun
+	upgrade.Labels = labels
+
+	opts, name := b.chartPathOptions(sc, r)
+	upgrade.ChartPathOptions = opts
+	upgrade.SetRegistryClient(rc)
+	ch, err := b.loadChart(sc, r, &upgrade.ChartPathOptions, name)
+	if err != nil {
+		return nil, err
+	}
+	return upgrade.RunWithContext(ctx, r.Name, ch, vals)
+}
+
+// releaseTimeout returns release timeout, helm default is used if it is not set.
+func releaseTimeout(r *config.Release) time.Duration {
+	if timeout, err := time.ParseDuration(r.Timeout); err == nil {
+		return timeout
+	}
+	return 5 * time.Minute
+}
+
+// upgrade installs or upgrades release.
+func (b *sdkBackend) upgrade(ctx context.Context, sc *ShellClient, r *config.Release, in *InstallOptions) (string, error) {
+	sc.l.Infof("Upgrade helm release %s with helm SDK", r.Name)
+	rel, err := b.render(ctx, sc, r, in, sc.opts.DryRun)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Release %s: revision %d, status %s", rel.Name, rel.Version, rel.Info.Status), nil
+}
+
+// diff compares installed release manifest with manifest rendered in dry-run mode.
+func (b *sdkBackend) diff(ctx context.Context, sc *ShellClient, r *config.Release) (bool, string, error) {
+	current := ""
+	installed, err := b.exists(ctx, sc, r.Name, r.Namespace)
+	if err != nil {
+		return false, "", err
+	}
+	if installed {
+		cfg, err := b.actionConfig(sc, r.Namespace)
+		if err != nil {
+			return false, "", err
+		}
+		rel, err := action.NewGet(cfg).Run(r.Name)
+		if err != nil {
+			return false, "", err
+		}
+		current = rel.Manifest
+	}
+
+	rel, err := b.render(ctx, sc, r, nil, true)
+	if err != nil {
+		return false, "", err
+	}
+
+	out, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(current),
+		B:        difflib.SplitLines(rel.Manifest),
+		FromFile: r.Name + " (installed)",
+		ToFile:   r.Name + " (configured)",
+		Context:  3,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	return out != "", out, nil
+}
+
+// exists checks if release has history.
+func (b *sdkBackend) exists(ctx context.Context, sc *ShellClient, name, namespace string) (bool, error) {
+	cfg, err := b.actionConfig(sc, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	history := action.NewHistory(cfg)
+	history.Max = 1
+	if _, err := history.Run(name); err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// uninstall uninstalls release. It returns false if release is not installed.
+func (b *sdkBackend) uninstall(ctx context.Context, sc *ShellClient, name, namespace string) (bool, string, error) {
+	cfg, err := b.actionConfig(sc, namespace)
+	if err != nil {
+		return false, "", err
+	}
+
+	uninstall := action.NewUninstall(cfg)
+	uninstall.DryRun = sc.opts.DryRun
+	res, err := uninstall.Run(name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return false, "", nil
+		}
+		return false, "", err
+	}
+
+	return true, fmt.Sprintf("release \"%s\" uninstalled\n%s", name, res.Info), nil
+}
+
+// list returns releases installed by helmctl to the target.
+func (b *sdkBackend) list(ctx context.Context, sc *ShellClient, target string, targetType config.TargetType) ([]*listedRelease, error) {
+	cfg, err := b.actionConfig(sc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	list := action.NewList(cfg)
+	list.AllNamespaces = true
+	list.All = true
+	list.SetStateMask()
+	list.Selector = labelsString(releaseLabels(target, targetType))
+
+	rels, err := list.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*listedRelease, 0, len(rels))
+	for _, rel := range rels {
+		releases = append(releases, &listedRelease{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Revision:  fmt.Sprint(rel.Version),
+			Status:    rel.Info.Status.String(),
+			Chart:     rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version,
+		})
+	}
+
+	return releases, nil
+}
+
+// rollback rolls back release to revision, previous revision is used if zero.
+func (b *sdkBackend) rollback(ctx context.Context, sc *ShellClient, name, namespace string, revision int) (string, error) {
+	cfg, err := b.actionConfig(sc, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	rollback := action.NewRollback(cfg)
+	rollback.Version = revision
+	rollback.DryRun = sc.opts.DryRun
+	if err := rollback.Run(name); err != nil {
+		return "", err
+	}
+
+	return "Rollback was a success!", nil
+}
+
+// template renders release manifests into dir.
+func (b *sdkBackend) template(ctx context.Context, sc *ShellClient, r *config.Release, dir string) error {
+	vals, err := b.values(sc, r)
+	if err != nil {
+		return err
+	}
+	rc, err := b.registryClient()
+	if err != nil {
+		return err
+	}
+
+	install := action.NewInstall(&action.Configuration{})
+	install.ReleaseName = r.Name
+	install.Namespace = r.Namespace
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = !*r.SkipCRDs
+	// manifests are written to OutputDir/<release name>
+	install.OutputDir = filepath.Dir(dir)
+	install.UseReleaseName = true
+
+	opts, name := b.chartPathOptions(sc, r)
+	install.ChartPathOptions = opts
+	install.SetRegistryClient(rc)
+	ch, err := b.loadChart(sc, r, &install.ChartPathOptions, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = install.Run(ch, vals)
+	return err
+}
+
+// lint lints release chart with release values.
+func (b *sdkBackend) lint(ctx context.Context, sc *ShellClient, r *config.Release, dir string) (string, error) {
+	vals, err := b.values(sc, r)
+	if err != nil {
+		return "", err
+	}
+
+	path, _ := sc.chartRef(r)
+	if !isLocalChart(path) {
+		rc, err := b.registryClient()
+		if err != nil {
+			return "", err
+		}
+		// install action is used to locate chart with registry client
+		locate := action.NewInstall(&action.Configuration{})
+		opts, name := b.chartPathOptions(sc, r)
+		locate.ChartPathOptions = opts
+		locate.SetRegistryClient(rc)
+		if path, err = locate.LocateChart(name, b.settings); err != nil {
+			return "", err
+		}
+	}
+
+	lint := action.NewLint()
+	lint.Namespace = r.Namespace
+	res := lint.Run([]string{path}, vals)
+
+	messages := []string{}
+	for _, m := range res.Messages {
+		messages = append(messages, m.Error())
+	}
+	out := strings.Join(messages, "\n")
+
+	if len(res.Errors) > 0 {
+		return out, fmt.Errorf("chart %s has %d linting errors", r.Chart, len(res.Errors))
+	}
+
+	return out, nil
+}
+
+// repoAdd downloads repository index and adds repository to helm repositories file.
+// OCI registries are not added to repositories file, registry login is done instead.
+// Present repository is always replaced, so force is not used.
+func (b *sdkBackend) repoAdd(ctx context.Context, sc *ShellClient, repoCfg *config.Repository, force bool) error {
+	if registry.IsOCI(repoCfg.URL) {
+		return b.registryLogin(sc, repoCfg)
+	}
+
+	entry := repoEntry(repoCfg)
+
+	if err := b.indexDownload(entry); err != nil {
+		return err
+	}
+
+	return b.reposFileUpdate(func(f *repo.File) {
+		f.Update(entry)
+	})
+}
+
+// registryLogin logs in to OCI registry, registry without credentials is skipped.
+func (b *sdkBackend) registryLogin(sc *ShellClient, repoCfg *config.Repository) error {
+	if repoCfg.User == "" || repoCfg.Password == "" {
+		sc.l.Debugf("Skip login to %s registry without credentials", repoCfg.Name)
+		return nil
+	}
+
+	rc, err := b.registryClient()
+	if err != nil {
+		return err
+	}
+
+	return rc.Login(registryHost(repoCfg.URL),
+		registry.LoginOptBasicAuth(repoCfg.User, repoCfg.Password),
+		registry.LoginOptTLSClientConfig(repoCfg.CertFile, repoCfg.KeyFile, repoCfg.CAFile),
+		registry.LoginOptInsecure(repoCfg.InsecureSkipTLSVerify),
+	)
+}
+
+// repoList returns repositories of helm repositories file, missing file has no repositories.
+func (b *sdkBackend) repoList(ctx context.Context, sc *ShellClient) ([]*config.Repository, error) {
+	if _, err := os.Stat(b.settings.RepositoryConfig); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	f, err := repo.LoadFile(b.settings.RepositoryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*config.Repository, 0, len(f.Repositories))
+	for _, entry := range f.Repositories {
+		repos = append(repos, &config.Repository{Name: entry.Name, URL: entry.URL})
+	}
+	return repos, nil
+}
+
+// repoUpdate downloads indexes of named repositories from helm repositories file.
+func (b *sdkBackend) repoUpdate(ctx context.Context, sc *ShellClient, names []string) error {
+	f, err := repo.LoadFile(b.settings.RepositoryConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		entry := f.Get(name)
+		if entry == nil {
+			return fmt.Errorf("no repository named %s found", name)
+		}
+		sc.l.Debugf("Update helm repository %s", entry.Name)
+		if err := b.indexDownload(entry); err != nil {
+			return fmt.Errorf("Unable to get an update from the %s chart repository, %v", entry.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// repoRemove removes repository from helm repositories file, OCI registries are skipped.
+func (b *sdkBackend) repoRemove(ctx context.Context, sc *ShellClient, repoCfg *config.Repository) error {
+	if registry.IsOCI(repoCfg.URL) {
+		return nil
+	}
+
+	return b.reposFileUpdate(func(f *repo.File) {
+		f.Remove(repoCfg.Name)
+	})
+}
+
+// args returns nil, helm binary is not used.
+func (b *sdkBackend) args(sc *ShellClient, r *config.Release, in *InstallOptions) []string {
+	return nil
+}
+
+// indexDownload downloads repository index to helm cache.
+func (b *sdkBackend) indexDownload(entry *repo.Entry) error {
+	r, err := repo.NewChartRepository(entry, getter.All(b.settings))
+	if err != nil {
+		return err
+	}
+	r.CachePath = b.settings.RepositoryCache
+
+	_, err = r.DownloadIndexFile()
+	return err
+}
+
+// reposFileUpdate modifies helm repositories file.
+func (b *sdkBackend) reposFileUpdate(update func(f *repo.File)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f, err := repo.LoadFile(b.settings.RepositoryConfig)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		f = repo.NewFile()
+	}
+
+	update(f)
+
+	if err := os.MkdirAll(filepath.Dir(b.settings.RepositoryConfig), 0755); err != nil {
+		return err
+	}
+	return f.WriteFile(b.settings.RepositoryConfig, 0600)
+}
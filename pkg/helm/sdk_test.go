@@ -0,0 +1,72 @@
+package helm
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestNewClient(t *testing.T) {
+	log := logrus.New()
+	cfg := config.NewConfigFromFile("testdata/helmctl.yaml", "", log, false)
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewClient(BackendShell, cfg, NewShellClientOptions(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.(*ShellClient); !ok {
+		t.Errorf("shell backend returned %T", h)
+	}
+
+	h, err = NewClient(BackendSDK, cfg, NewShellClientOptions(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.(*SDKClient); !ok {
+		t.Errorf("sdk backend returned %T", h)
+	}
+
+	if _, err := NewClient("unknown", cfg, nil); err == nil {
+		t.Error("unknown backend was accepted")
+	}
+}
+
+func TestSDKTemplate(t *testing.T) {
+	log := logrus.New()
+	sc := &ShellClient{l: log, opts: NewShellClientOptions(log), cfg: config.NewConfigFromFile("", "", log, false)}
+	sc.charts = &chartResolver{sc: sc}
+	b := &sdkBackend{settings: cli.New()}
+
+	disabled := false
+	r := &config.Release{
+		Name:       "app",
+		Namespace:  "default",
+		Chart:      "./testdata/charts/app",
+		Atomic:     &disabled,
+		SkipCRDs:   &disabled,
+		Repository: &config.Repository{},
+		Values:     []*config.Value{{Name: "message", Value: "configured", Type: "string"}},
+	}
+
+	dir := t.TempDir()
+	if err := b.template(context.Background(), sc, r, filepath.Join(dir, r.Name)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, "app", "app", "templates", "configmap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `message: "configured"`) {
+		t.Errorf("unexpected manifest:\n%s", out)
+	}
+}
@@ -0,0 +1,405 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// shellBackend executes helm operations as shell calls to helm binary.
+type shellBackend struct{}
+
+// helm executes helm binary and returns its combined output.
+func (b *shellBackend) helm(ctx context.Context, sc *ShellClient, args ...string) (string, error) {
+	sc.l.Infof("Execute helm command: %s %s", sc.opts.HelmPath, strings.Join(args, " "))
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: args})
+	if err != nil {
+		return string(out), fmt.Errorf("%s, %w", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	return string(out), nil
+}
+
+// upgrade executes helm upgrade --install.
+func (b *shellBackend) upgrade(ctx context.Context, sc *ShellClient, r *config.Release, in *InstallOptions) (string, error) {
+	return b.helm(ctx, sc, sc.buildArgs(r, in)...)
+}
+
+// diff executes helm diff upgrade, helm diff exits with code 2 if there are changes.
+func (b *shellBackend) diff(ctx context.Context, sc *ShellClient, r *config.Release) (bool, string, error) {
+	out, err := b.helm(ctx, sc, sc.diffArgs(r)...)
+	if err != nil {
+		if exitCode(err) == 2 {
+			return true, out, nil
+		}
+		return false, out, err
+	}
+
+	return false, out, nil
+}
+
+// exists checks if release is installed with helm status.
+func (b *shellBackend) exists(ctx context.Context, sc *ShellClient, name, namespace string) (bool, error) {
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: []string{"status", name, "--namespace", namespace}})
+	if err != nil {
+		if strings.Contains(string(out), "not found") {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	return true, nil
+}
+
+// uninstall executes helm uninstall. It returns false if release is not installed.
+func (b *shellBackend) uninstall(ctx context.Context, sc *ShellClient, name, namespace string) (bool, string, error) {
+	args := []string{"uninstall", name, "--namespace", namespace}
+	if sc.opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	out, err := b.helm(ctx, sc, args...)
+	if err != nil {
+		if strings.Contains(out, "not found") {
+			return false, out, nil
+		}
+		return false, out, err
+	}
+
+	return true, out, nil
+}
+
+// list returns releases installed by helmctl to the target with helm list.
+func (b *shellBackend) list(ctx context.Context, sc *ShellClient, target string, targetType config.TargetType) ([]*listedRelease, error) {
+	args := []string{
+		"list", "--all-namespaces", "--all", "--output", "json",
+		"--selector", labelsString(releaseLabels(target, targetType)),
+	}
+
+	sc.l.Debugf("Execute helm command: %s %s", sc.opts.HelmPath, strings.Join(args, " "))
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: args, StdoutOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	releases := []*listedRelease{}
+	if err := json.Unmarshal(out, &releases); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// rollback executes helm rollback.
+func (b *shellBackend) rollback(ctx context.Context, sc *ShellClient, name, namespace string, revision int) (string, error) {
+	args := []string{"rollback", name}
+	if revision != 0 {
+		args = append(args, strconv.Itoa(revision))
+	}
+	args = append(args, "--namespace", namespace)
+	if sc.opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	return b.helm(ctx, sc, args...)
+}
+
+// template executes helm template with output to dir.
+func (b *shellBackend) template(ctx context.Context, sc *ShellClient, r *config.Release, dir string) error {
+	chart, version := sc.chartRef(r)
+	args := []string{"template", r.Name, chart, "--namespace", r.Namespace}
+	if !*r.SkipCRDs {
+		args = append(args, "--include-crds")
+	}
+	args = append(args, "--output-dir", dir)
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	args = append(args, sc.valuesArgs(r)...)
+
+	_, err := b.helm(ctx, sc, args...)
+	return err
+}
+
+// lint executes helm lint, chart is pulled to dir if it is not a local chart.
+func (b *shellBackend) lint(ctx context.Context, sc *ShellClient, r *config.Release, dir string) (string, error) {
+	chart, version := sc.chartRef(r)
+	if !isLocalChart(chart) {
+		args := []string{"pull", chart, "--untar", "--untardir", dir}
+		if version != "" {
+			args = append(args, "--version", version)
+		}
+		if _, err := b.helm(ctx, sc, args...); err != nil {
+			return "", err
+		}
+		chart = filepath.Join(dir, path.Base(r.Chart))
+	}
+
+	args := []string{"lint", chart, "--namespace", r.Namespace}
+	args = append(args, sc.valuesArgs(r)...)
+
+	return b.helm(ctx, sc, args...)
+}
+
+// repoAdd executes helm repo add, credentials are passed with standard input.
+// OCI registries are not added to repositories, helm registry login is executed instead.
+func (b *shellBackend) repoAdd(ctx context.Context, sc *ShellClient, repo *config.Repository, force bool) error {
+	if registry.IsOCI(repo.URL) {
+		return b.registryLogin(ctx, sc, repo)
+	}
+
+	u, err := url.Parse(repo.URL)
+	if err != nil {
+		return err
+	}
+
+	if u.Host == "" || u.Scheme == "" {
+		return errors.New("invalid repository url: <schema>://<host> format required")
+	}
+
+	c := &Command{Path: sc.opts.HelmPath, Args: []string{"repo", "add", repo.Name, repo.URL}}
+	if repo.User != "" && repo.Password != "" {
+		c.Args = append(c.Args, "--username", repo.User, "--password-stdin")
+		c.Stdin = repo.Password
+	}
+	c.Args = append(c.Args, repoTLSArgs(repo, "--insecure-skip-tls-verify")...)
+	if repo.PassCredentials {
+		c.Args = append(c.Args, "--pass-credentials")
+	}
+	if force {
+		c.Args = append(c.Args, "--force-update")
+	}
+
+	out, err := sc.runner.Run(ctx, c)
+	if err != nil {
+		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	return nil
+}
+
+// registryLogin executes helm registry login, registry without credentials is skipped.
+func (b *shellBackend) registryLogin(ctx context.Context, sc *ShellClient, repo *config.Repository) error {
+	if repo.User == "" || repo.Password == "" {
+		sc.l.Debugf("Skip login to %s registry without credentials", repo.Name)
+		return nil
+	}
+
+	c := &Command{
+		Path:  sc.opts.HelmPath,
+		Args:  []string{"registry", "login", registryHost(repo.URL), "--username", repo.User, "--password-stdin"},
+		Stdin: repo.Password,
+	}
+	c.Args = append(c.Args, repoTLSArgs(repo, "--insecure")...)
+
+	out, err := sc.runner.Run(ctx, c)
+	if err != nil {
+		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	return nil
+}
+
+// repoTLSArgs returns TLS arguments of repository, insecure is name of
+// flag which disables certificate verification.
+func repoTLSArgs(repo *config.Repository, insecure string) []string {
+	args := []string{}
+	if repo.CAFile != "" {
+		args = append(args, "--ca-file", repo.CAFile)
+	}
+	if repo.CertFile != "" {
+		args = append(args, "--cert-file", repo.CertFile)
+	}
+	if repo.KeyFile != "" {
+		args = append(args, "--key-file", repo.KeyFile)
+	}
+	if repo.InsecureSkipTLSVerify {
+		args = append(args, insecure)
+	}
+	return args
+}
+
+// registryHost returns host of OCI registry URL.
+func registryHost(ref string) string {
+	return strings.SplitN(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme)), "/", 2)[0]
+}
+
+// repoList executes helm repo list, helm fails if there are no repositories.
+func (b *shellBackend) repoList(ctx context.Context, sc *ShellClient) ([]*config.Repository, error) {
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: []string{"repo", "list", "-o", "json"}, StdoutOnly: true})
+	if err != nil {
+		if exitCode(err) == 1 {
+			sc.l.Debugf("No helm repositories are listed, %v", err)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	repos := []*config.Repository{}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return repos, nil
+	}
+	if err := json.Unmarshal(out, &repos); err != nil {
+		return nil, fmt.Errorf("cannot parse helm repo list output, %v", err)
+	}
+	return repos, nil
+}
+
+// repoUpdate executes helm repo update for named repositories.
+func (b *shellBackend) repoUpdate(ctx context.Context, sc *ShellClient, names []string) error {
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: append([]string{"repo", "update"}, names...)})
+	if err != nil {
+		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	if strings.Contains(string(out), "Unable to get") {
+		return fmt.Errorf("Error: %s", strings.ReplaceAll(string(out), "\n", ""))
+	}
+
+	return nil
+}
+
+// repoRemove executes helm repo remove, OCI registries are skipped.
+func (b *shellBackend) repoRemove(ctx context.Context, sc *ShellClient, repo *config.Repository) error {
+	if registry.IsOCI(repo.URL) {
+		return nil
+	}
+
+	out, err := sc.runner.Run(ctx, &Command{Path: sc.opts.HelmPath, Args: []string{"repo", "remove", repo.Name}})
+	if err != nil {
+		return fmt.Errorf("%s, %v", strings.ReplaceAll(string(out), "\n", ""), err)
+	}
+
+	return nil
+}
+
+// args returns helm arguments of release upgrade or diff.
+func (b *shellBackend) args(sc *ShellClient, r *config.Release, in *InstallOptions) []string {
+	if sc.opts.Diff {
+		return sc.diffArgs(r)
+	}
+	return sc.buildArgs(r, in)
+}
+
+// diffArgs returns helm diff upgrade arguments.
+func (sc *ShellClient) diffArgs(r *config.Release) []string {
+	chart, version := sc.chartRef(r)
+	args := []string{"diff", "upgrade", "--allow-unreleased", "--detailed-exitcode", r.Name, "--namespace", r.Namespace}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if *r.ResetValues {
+		args = append(args, "--reset-values")
+	}
+	if *r.ReuseValues {
+		args = append(args, "--reuse-values")
+	}
+	if *r.DisableHooks {
+		args = append(args, "--no-hooks")
+	}
+	args = append(args, sc.valuesArgs(r)...)
+	args = append(args, sc.postRendererArgs(r)...)
+	args = append(args, chart)
+
+	return args
+}
+
+// buildArgs returns helm upgrade arguments.
+func (sc *ShellClient) buildArgs(r *config.Release, in *InstallOptions) []string {
+	args := []string{"upgrade", "-i", r.Name, "--namespace", r.Namespace}
+	args = append(args, "--labels", labelsString(releaseLabels(in.Target, in.TargetType)))
+
+	chart, version := sc.chartRef(r)
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	args = append(args, upgradeFlags(r)...)
+	args = append(args, sc.valuesArgs(r)...)
+	args = append(args, sc.postRendererArgs(r)...)
+
+	if sc.opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	// extra arguments are passed as is, so they could override the ones above
+	args = append(args, r.ExtraArgs...)
+	args = append(args, chart)
+
+	return args
+}
+
+// upgradeFlags returns helm upgrade arguments of release options.
+func upgradeFlags(r *config.Release) []string {
+	args := []string{}
+	for _, flag := range []struct {
+		enabled bool
+		name    string
+	}{
+		{*r.Atomic, "--atomic"},
+		{*r.Wait, "--wait"},
+		{*r.WaitForJobs, "--wait-for-jobs"},
+		{*r.Force, "--force"},
+		{*r.CleanupOnFail, "--cleanup-on-fail"},
+		{*r.SkipCRDs, "--skip-crds"},
+		{*r.ResetValues, "--reset-values"},
+		{*r.ReuseValues, "--reuse-values"},
+		{*r.DisableHooks, "--no-hooks"},
+	} {
+		if flag.enabled {
+			args = append(args, flag.name)
+		}
+	}
+
+	if r.Timeout != "" {
+		args = append(args, "--timeout", r.Timeout)
+	}
+	if r.MaxHistory != nil {
+		args = append(args, "--history-max", strconv.Itoa(*r.MaxHistory))
+	}
+	if r.Description != "" {
+		args = append(args, "--description", r.Description)
+	}
+
+	return args
+}
+
+// valuesArgs returns helm arguments with release value files and values.
+// Decrypted copies of encrypted value files are used.
+func (sc *ShellClient) valuesArgs(r *config.Release) []string {
+	args := []string{}
+
+	for _, vf := range r.ValueFiles {
+		args = append(args, "-f", sc.valueFilePath(vf))
+	}
+
+	for _, v := range r.Values {
+		if v.Type == "string" {
+			args = append(args, "--set-string", v.GetKeyValuePair())
+			continue
+		}
+		args = append(args, "--set", v.GetKeyValuePair())
+	}
+
+	return args
+}
+
+// labelsString returns labels in key=value,... format sorted by key.
+func labelsString(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,224 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"go.mozilla.org/sops/v3"
+	sopsconfig "go.mozilla.org/sops/v3/config"
+	"go.mozilla.org/sops/v3/decrypt"
+	"go.mozilla.org/sops/v3/stores/dotenv"
+	"go.mozilla.org/sops/v3/stores/ini"
+	sopsyaml "go.mozilla.org/sops/v3/stores/yaml"
+)
+
+// tmpfsDir is used for decrypted value files when available, so secrets are not written to disk.
+const tmpfsDir = "/dev/shm"
+
+// sopsDecryptFile decrypts file encrypted with sops, replaced in tests.
+var sopsDecryptFile = decrypt.File
+
+// decryptedFiles contains decrypted copies of value files. Copies are written
+// to a private temp directory which is created with the first decrypted file.
+type decryptedFiles struct {
+	mu  sync.Mutex
+	dir string
+	// paths maps encrypted value files to decrypted copies.
+	paths map[string]string
+}
+
+// path returns decrypted copy of value file or the file itself if it is not decrypted.
+func (d *decryptedFiles) path(name string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if p, ok := d.paths[name]; ok {
+		return p
+	}
+	return name
+}
+
+// decrypt decrypts value file in sops format, file decrypted in the run is not decrypted again.
+func (d *decryptedFiles) decrypt(name, format string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.paths[name]; ok {
+		return nil
+	}
+
+	if d.dir == "" {
+		dir, err := ioutil.TempDir(tempBase(), "helmctl-")
+		if err != nil {
+			return err
+		}
+		d.dir = dir
+		d.paths = map[string]string{}
+	}
+
+	b, err := sopsDecryptFile(name, format)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt %s, %v", name, err)
+	}
+	if b, err = sopsValues(b, format); err != nil {
+		return fmt.Errorf("cannot convert decrypted %s to values, %v", name, err)
+	}
+
+	p := filepath.Join(d.dir, fmt.Sprintf("%d-%s", len(d.paths), filepath.Base(name)))
+	if err := ioutil.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	d.paths[name] = p
+
+	return nil
+}
+
+// sopsValues converts decrypted file in dotenv or ini format to YAML, because helm
+// reads value files only in YAML. Variables of dotenv file become top level
+// values, sections of ini file become maps of their keys.
+func sopsValues(data []byte, format string) ([]byte, error) {
+	var branches sops.TreeBranches
+	var err error
+	switch format {
+	case "dotenv":
+		branches, err = (&dotenv.Store{}).LoadPlainFile(data)
+	case "ini":
+		if branches, err = (&ini.Store{}).LoadPlainFile(data); err == nil {
+			branches = iniSectionsNotEmpty(branches)
+		}
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return (&sopsyaml.Store{}).EmitPlainFile(branches)
+}
+
+// iniSectionsNotEmpty removes empty sections, DEFAULT section is always loaded.
+func iniSectionsNotEmpty(branches sops.TreeBranches) sops.TreeBranches {
+	for idx, branch := range branches {
+		sections := sops.TreeBranch{}
+		for _, item := range branch {
+			if keys, ok := item.Value.(sops.TreeBranch); ok && len(keys) == 0 {
+				continue
+			}
+			sections = append(sections, item)
+		}
+		branches[idx] = sections
+	}
+	return branches
+}
+
+// remove removes decrypted copies with temp directory.
+func (d *decryptedFiles) remove() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dir == "" {
+		return nil
+	}
+
+	dir := d.dir
+	d.dir = ""
+	d.paths = nil
+	return os.RemoveAll(dir)
+}
+
+// tempBase returns directory for temp directory of decrypted files.
+func tempBase() string {
+	if fi, err := os.Stat(tmpfsDir); err == nil && fi.IsDir() {
+		if f, err := ioutil.TempFile(tmpfsDir, ".helmctl-"); err == nil {
+			f.Close()
+			os.Remove(f.Name())
+			return tmpfsDir
+		}
+	}
+	return os.TempDir()
+}
+
+// sopsDecrypt decrypts value files encrypted with sops, value files are not modified.
+func (sc *ShellClient) sopsDecrypt(vfs []*config.ValueFile) error {
+	for _, vf := range vfs {
+		if vf.GetDecrypt() {
+			format := sopsFormat(vf)
+			sc.l.Infof("Decrypt helm value file %s in %s format", vf.Name, format)
+			sc.sopsRuleWarn(vf.Name)
+			if err := sc.decrypted.decrypt(vf.Name, format); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sopsFormat returns sops format of value file set in config or detected by file extension,
+// it is yaml for other extensions. Binary format is used only if it is set in config.
+func sopsFormat(vf *config.ValueFile) string {
+	if vf.Format != "" {
+		return vf.Format
+	}
+
+	switch strings.ToLower(filepath.Ext(vf.Name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".env":
+		return "dotenv"
+	case ".ini":
+		return "ini"
+	default:
+		return "yaml"
+	}
+}
+
+// sopsRuleWarn warns if sops config has no creation rule for value file.
+// Keys of the matched rule are the keys sops CLI uses for the file, so a
+// missing rule usually means that the file is encrypted with unexpected keys.
+// It is advisory only, decryption uses keys from metadata of the file.
+func (sc *ShellClient) sopsRuleWarn(name string) {
+	if sc.opts.SopsConfig == "" {
+		return
+	}
+	if _, err := os.Stat(sc.opts.SopsConfig); err != nil {
+		sc.l.Debugf("Skip sops config check, %v", err)
+		return
+	}
+
+	path, err := filepath.Abs(name)
+	if err != nil {
+		path = name
+	}
+
+	rule, err := sopsconfig.LoadCreationRuleForFile(sc.opts.SopsConfig, path, nil)
+	if err != nil {
+		sc.l.Warnf("Sops config %s has no rule for %s, %v", sc.opts.SopsConfig, name, err)
+		return
+	}
+	if rule != nil {
+		sc.l.Debugf("Sops config %s has rule for %s with %d key groups", sc.opts.SopsConfig, name, len(rule.KeyGroups))
+	}
+}
+
+// decryptedRemove removes decrypted value files.
+func (sc *ShellClient) decryptedRemove() {
+	if err := sc.decrypted.remove(); err != nil {
+		sc.l.Warnf("Failed to remove decrypted value files, %v", err)
+	}
+}
+
+// valueFilePath returns path of value file passed to helm.
+func (sc *ShellClient) valueFilePath(vf *config.ValueFile) string {
+	if vf.GetDecrypt() {
+		return sc.decrypted.path(vf.Name)
+	}
+	return vf.Name
+}
@@ -0,0 +1,169 @@
+package helm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSopsDecrypt(t *testing.T) {
+	defer func(orig func(string, string) ([]byte, error)) { sopsDecryptFile = orig }(sopsDecryptFile)
+	sopsDecryptFile = func(path, format string) ([]byte, error) {
+		if format != "yaml" {
+			t.Errorf("unexpected format %s of %s", format, path)
+		}
+		return []byte("password: plain\n"), nil
+	}
+
+	var decrypted string
+	runner := &fakeRunner{hook: func(ctx context.Context, c *Command) error {
+		if c.Args[0] != "upgrade" {
+			return nil
+		}
+
+		// decrypted file is passed to helm instead of the encrypted one
+		for i, arg := range c.Args {
+			if arg == "-f" {
+				decrypted = c.Args[i+1]
+				break
+			}
+		}
+
+		fi, err := os.Stat(decrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("decrypted file mode %v", fi.Mode().Perm())
+		}
+		di, err := os.Stat(filepath.Dir(decrypted))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if di.Mode().Perm() != 0700 {
+			t.Errorf("decrypted directory mode %v", di.Mode().Perm())
+		}
+		b, err := ioutil.ReadFile(decrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "password: plain\n" {
+			t.Errorf("unexpected decrypted content %q", b)
+		}
+		return nil
+	}}
+
+	log := logrus.New()
+	cfg := config.NewConfigFromFile("testdata/helmctl-sops.yaml", "", log, false)
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	opts := NewShellClientOptions(log)
+	opts.Runner = runner
+	h, err := NewShellClient(cfg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fail := range []bool{false, true} {
+		runner.responses = nil
+		if fail {
+			runner.on("helm upgrade", "Error: failed", &fakeExitError{code: 1})
+		}
+
+		in := &InstallOptions{
+			Release:          "app",
+			Target:           "development",
+			TargetType:       config.TargetEnvironments,
+			KubernetesClient: fake.NewSimpleClientset(),
+		}
+		if err := h.Install(context.Background(), in); (err != nil) != fail {
+			t.Fatalf("unexpected install result %v", err)
+		}
+
+		if decrypted == "" || decrypted == filepath.Join("testdata", "secrets.yaml") {
+			t.Fatalf("decrypted file is not used, got %s", decrypted)
+		}
+		if _, err := os.Stat(filepath.Dir(decrypted)); !os.IsNotExist(err) {
+			t.Errorf("decrypted files are not removed, %v", err)
+		}
+	}
+
+	// value file of config is not modified
+	r, err := cfg.TargetRelease("app", "development", config.TargetEnvironments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ValueFiles[0].Name != filepath.Join("testdata", "secrets.yaml") {
+		t.Errorf("value file was modified: %s", r.ValueFiles[0].Name)
+	}
+}
+
+func TestSopsFormat(t *testing.T) {
+	tests := []struct {
+		vf   *config.ValueFile
+		want string
+	}{
+		{&config.ValueFile{Name: "secrets.yaml"}, "yaml"},
+		{&config.ValueFile{Name: "secrets.YML"}, "yaml"},
+		{&config.ValueFile{Name: "secrets.json"}, "json"},
+		{&config.ValueFile{Name: "secrets.env"}, "dotenv"},
+		{&config.ValueFile{Name: "secrets.ini"}, "ini"},
+		{&config.ValueFile{Name: "secrets"}, "yaml"},
+		{&config.ValueFile{Name: "secrets.txt"}, "yaml"},
+		{&config.ValueFile{Name: "secrets.txt", Format: "binary"}, "binary"},
+	}
+
+	for _, tt := range tests {
+		if got := sopsFormat(tt.vf); got != tt.want {
+			t.Errorf("%s: expected format %s, got %s", tt.vf.Name, tt.want, got)
+		}
+	}
+}
+
+func TestSopsDecryptValues(t *testing.T) {
+	defer func(orig func(string, string) ([]byte, error)) { sopsDecryptFile = orig }(sopsDecryptFile)
+	plain := map[string]string{
+		"dotenv": "# database\nPASSWORD=plain\nUSER=admin\n",
+		"ini":    "[database]\npassword = plain\nuser = admin\n",
+	}
+	sopsDecryptFile = func(path, format string) ([]byte, error) {
+		return []byte(plain[format]), nil
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   map[string]interface{}
+	}{
+		{"secrets.env", "dotenv", map[string]interface{}{"PASSWORD": "plain", "USER": "admin"}},
+		{"secrets.ini", "ini", map[string]interface{}{
+			"database": map[string]interface{}{"password": "plain", "user": "admin"},
+		}},
+	}
+
+	d := &decryptedFiles{}
+	defer d.remove()
+	for _, tt := range tests {
+		if err := d.decrypt(tt.name, tt.format); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		// decrypted file is passed to helm as value file
+		values, err := chartutil.ReadValuesFile(d.path(tt.name))
+		if err != nil {
+			t.Fatalf("%s: decrypted file is not values, %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(map[string]interface{}(values), tt.want) {
+			t.Errorf("%s: expected values %v, got %v", tt.name, tt.want, values)
+		}
+	}
+}
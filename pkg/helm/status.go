@@ -0,0 +1,71 @@
+package helm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	helmctlKubernetes "github.com/sprokhorov/helmctl/pkg/kubernetes"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Define release status problems
+const (
+	ProblemMissing         = "missing"
+	ProblemVersionMismatch = "version mismatch"
+	ProblemFailed          = "failed"
+	ProblemPending         = "pending"
+)
+
+// ReleaseStatus contains deployed state of configured release.
+type ReleaseStatus struct {
+	Release           string
+	Namespace         string
+	ConfiguredVersion string
+	DeployedVersion   string
+	AppVersion        string
+	Revision          int
+	Status            string
+	Problems          []string
+}
+
+// Status returns deployed state of releases. Data is read from helm storage secrets.
+func Status(ctx context.Context, client kubernetes.Interface, releases []*config.Release) ([]*ReleaseStatus, error) {
+	statuses := make([]*ReleaseStatus, 0, len(releases))
+
+	for _, r := range releases {
+		s := &ReleaseStatus{
+			Release:           r.Name,
+			Namespace:         r.Namespace,
+			ConfiguredVersion: r.Version,
+			Problems:          []string{},
+		}
+		statuses = append(statuses, s)
+
+		deployed, err := helmctlKubernetes.LatestHelmRelease(ctx, client, r.Namespace, r.Name)
+		if err != nil {
+			return statuses, err
+		}
+		if deployed == nil {
+			s.Problems = append(s.Problems, ProblemMissing)
+			continue
+		}
+
+		s.DeployedVersion = deployed.Chart.Metadata.Version
+		s.AppVersion = deployed.Chart.Metadata.AppVersion
+		s.Revision = deployed.Version
+		s.Status = deployed.Info.Status
+
+		if r.Version != "" && strings.TrimPrefix(r.Version, "v") != strings.TrimPrefix(s.DeployedVersion, "v") {
+			s.Problems = append(s.Problems, ProblemVersionMismatch)
+		}
+		switch {
+		case s.Status == "failed":
+			s.Problems = append(s.Problems, ProblemFailed)
+		case strings.HasPrefix(s.Status, "pending"):
+			s.Problems = append(s.Problems, ProblemPending)
+		}
+	}
+
+	return statuses, nil
+}
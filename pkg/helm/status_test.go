@@ -0,0 +1,53 @@
+package helm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+	"github.com/sprokhorov/helmctl/pkg/kubernetes/kubetest"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStatus(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		kubetest.HelmReleaseSecret("synced", "apps", 1, "deployed", "1.0.0"),
+		kubetest.HelmReleaseSecret("outdated", "apps", 4, "deployed", "1.0.0"),
+		kubetest.HelmReleaseSecret("broken", "apps", 2, "failed", "1.0.0"),
+		kubetest.HelmReleaseSecret("upgrading", "apps", 3, "pending-upgrade", "1.0.0"),
+	)
+
+	releases := []*config.Release{
+		{Name: "synced", Namespace: "apps", Version: "v1.0.0"},
+		{Name: "outdated", Namespace: "apps", Version: "1.1.0"},
+		{Name: "broken", Namespace: "apps"},
+		{Name: "upgrading", Namespace: "apps"},
+		{Name: "missing", Namespace: "apps"},
+	}
+
+	expected := map[string][]string{
+		"synced":    {},
+		"outdated":  {ProblemVersionMismatch},
+		"broken":    {ProblemFailed},
+		"upgrading": {ProblemPending},
+		"missing":   {ProblemMissing},
+	}
+
+	statuses, err := Status(context.Background(), client, releases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(releases) {
+		t.Fatalf("expected %d statuses, got %d", len(releases), len(statuses))
+	}
+
+	for _, s := range statuses {
+		if !reflect.DeepEqual(s.Problems, expected[s.Release]) {
+			t.Errorf("%s: expected problems %v, got %v", s.Release, expected[s.Release], s.Problems)
+		}
+	}
+	if statuses[1].Revision != 4 || statuses[1].DeployedVersion != "1.0.0" {
+		t.Errorf("unexpected deployed state %+v", statuses[1])
+	}
+}
@@ -0,0 +1,105 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sprokhorov/helmctl/pkg/config"
+)
+
+// TemplateOptions contains arguments for Template method.
+type TemplateOptions struct {
+	Release    string
+	Target     string
+	TargetType config.TargetType
+	OutputDir  string
+
+	// lock contains locked chart versions, nil if there is no lock file.
+	lock *Lock
+}
+
+// NewTemplateOptions creates new TemplateOptions object.
+func NewTemplateOptions() *TemplateOptions {
+	return &TemplateOptions{TargetType: config.TargetEnvironments, OutputDir: "rendered"}
+}
+
+// Template renders manifests of release or releases from config
+// into separate directory per release.
+func (sc *ShellClient) Template(ctx context.Context, in *TemplateOptions) error {
+	defer sc.decryptedRemove()
+	defer sc.chartsRemove()
+
+	if err := sc.targetReposSync(ctx, in.Release, in.Target, in.TargetType); err != nil {
+		sc.l.Errorf("Failed to add helm repositories, %v", err)
+		return err
+	}
+
+	lock, err := sc.lockLoad()
+	if err != nil {
+		return err
+	}
+	in.lock = lock
+
+	var releases []*config.Release
+	if in.Release == "all" {
+		releases, err = sc.cfg.TargetReleases(in.Target, in.TargetType)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err := sc.cfg.TargetRelease(in.Release, in.Target, in.TargetType)
+		if err != nil {
+			return err
+		}
+		releases = []*config.Release{r}
+	}
+
+	for _, r := range releases {
+		if err := sc.releaseTemplate(ctx, r, in); err != nil {
+			return fmt.Errorf("release %s: %v", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseTemplate renders release manifests into outputDir/<release name>.
+func (sc *ShellClient) releaseTemplate(ctx context.Context, r *config.Release, in *TemplateOptions) error {
+	sc.l.Infof("Render helm release %s", r.Name)
+
+	// pin locked chart version
+	if err := sc.lockCheck(in.lock, r, in.Target, in.TargetType); err != nil {
+		return err
+	}
+
+	// decrypt sops
+	if err := sc.sopsDecrypt(r.ValueFiles); err != nil {
+		return err
+	}
+
+	// remove manifests rendered before
+	dir := filepath.Join(in.OutputDir, r.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := sc.backend.template(ctx, sc, r, dir); err != nil {
+		return err
+	}
+
+	// helm writes manifests to output dir before post-renderer is run
+	if r.PostRender != nil {
+		pr, err := NewPostRenderer(r.PostRender)
+		if err != nil {
+			return err
+		}
+		if err := pr.RunDir(dir); err != nil {
+			return err
+		}
+	}
+	sc.l.Infof("Helm release %s was rendered to %s", r.Name, dir)
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+    "bytes"
+    "compress/gzip"
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "sort"
+    "strconv"
+
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes"
+)
+
+// Define helm storage secret labels
+const (
+    helmOwnerLabel   = "owner"
+    helmOwnerValue   = "helm"
+    helmNameLabel    = "name"
+    helmVersionLabel = "version"
+)
+
+// gzip magic header used by helm to compress release data
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease represents helm release stored in Kubernetes secret by helm.
+type HelmRelease struct {
+    Name      string `json:"name"`
+    Namespace string `json:"namespace"`
+    // Version is a release revision
+    Version int `json:"version"`
+    Info    struct {
+        Status      string `json:"status"`
+        Description string `json:"description"`
+    } `json:"info"`
+    Chart struct {
+        Metadata struct {
+            Name       string `json:"name"`
+            Version    string `json:"version"`
+            AppVersion string `json:"appVersion"`
+        } `json:"metadata"`
+    } `json:"chart"`
+}
+
+// LatestHelmRelease returns last revision of helm release from helm storage secrets.
+// It returns nil if release is not installed.
+func LatestHelmRelease(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (*HelmRelease, error) {
+    selector := fmt.Sprintf("%s=%s,%s=%s", helmOwnerLabel, helmOwnerValue, helmNameLabel, name)
+    secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+    if err != nil {
+        return nil, fmt.Errorf("cannot list helm secrets of release %s : %v", name, err)
+    }
+
+    var latest *v1.Secret
+    latestVersion := 0
+    for idx, s := range secrets.Items {
+        version, err := strconv.Atoi(s.Labels[helmVersionLabel])
+        if err != nil {
+            continue
+        }
+        if version > latestVersion {
+            latest = &secrets.Items[idx]
+            latestVersion = version
+        }
+    }
+    if latest == nil {
+        return nil, nil
+    }
+
+    release, err := DecodeHelmRelease(latest.Data["release"])
+    if err != nil {
+        return nil, fmt.Errorf("cannot decode helm release %s : %v", name, err)
+    }
+
+    return release, nil
+}
+
+// DecodeHelmRelease decodes release data from helm storage secret.
+// Data is base64 encoded and optionally gzipped JSON.
+func DecodeHelmRelease(data []byte) (*HelmRelease, error) {
+    b, err := base64.StdEncoding.DecodeString(string(data))
+    if err != nil {
+        return nil, err
+    }
+
+    if bytes.HasPrefix(b, gzipMagic) {
+        r, err := gzip.NewReader(bytes.NewReader(b))
+        if err != nil {
+            return nil, err
+        }
+        defer r.Close()
+        b, err = ioutil.ReadAll(r)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    release := &HelmRelease{}
+    if err := json.Unmarshal(b, release); err != nil {
+        return nil, err
+    }
+
+    return release, nil
+}
+
+// HelmReleaseNames returns names of helm releases stored in the namespace.
+func HelmReleaseNames(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]string, error) {
+    selector := fmt.Sprintf("%s=%s", helmOwnerLabel, helmOwnerValue)
+    secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+    if err != nil {
+        return nil, fmt.Errorf("cannot list helm secrets in namespace %s : %v", namespace, err)
+    }
+
+    names := []string{}
+    seen := make(map[string]struct{}, len(secrets.Items))
+    for _, s := range secrets.Items {
+        name := s.Labels[helmNameLabel]
+        if _, exists := seen[name]; exists || name == "" {
+            continue
+        }
+        seen[name] = struct{}{}
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    return names, nil
+}
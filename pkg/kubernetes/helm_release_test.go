@@ -0,0 +1,64 @@
+package kubernetes
+
+import (
+    "context"
+    "reflect"
+    "testing"
+
+    "github.com/sprokhorov/helmctl/pkg/kubernetes/kubetest"
+    "k8s.io/client-go/kubernetes/fake"
+)
+
+// TestLatestHelmRelease tests reading of helm release from storage secrets
+func TestLatestHelmRelease(t *testing.T) {
+    client := fake.NewSimpleClientset(
+        kubetest.HelmReleaseSecret("app", "apps", 1, "superseded", "0.1.0"),
+        kubetest.HelmReleaseSecret("app", "apps", 2, "deployed", "0.2.0"),
+        kubetest.HelmReleaseSecret("other", "apps", 3, "failed", "0.3.0"),
+    )
+
+    r, err := LatestHelmRelease(context.TODO(), client, "apps", "app")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if r == nil {
+        t.Fatal("release is not found")
+    }
+    if r.Version != 2 || r.Info.Status != "deployed" || r.Chart.Metadata.Version != "0.2.0" {
+        t.Errorf("unexpected release %+v", r)
+    }
+
+    r, err = LatestHelmRelease(context.TODO(), client, "apps", "missing")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if r != nil {
+        t.Errorf("expected missing release, got %+v", r)
+    }
+}
+
+// TestHelmReleaseNames tests listing of releases stored in namespace
+func TestHelmReleaseNames(t *testing.T) {
+    client := fake.NewSimpleClientset(
+        kubetest.HelmReleaseSecret("app", "apps", 1, "superseded", "0.1.0"),
+        kubetest.HelmReleaseSecret("app", "apps", 2, "deployed", "0.2.0"),
+        kubetest.HelmReleaseSecret("db", "apps", 1, "deployed", "0.1.0"),
+        kubetest.HelmReleaseSecret("other", "others", 1, "deployed", "0.1.0"),
+    )
+
+    names, err := HelmReleaseNames(context.TODO(), client, "apps")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(names, []string{"app", "db"}) {
+        t.Errorf("unexpected releases %v", names)
+    }
+
+    names, err = HelmReleaseNames(context.TODO(), client, "empty")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(names) != 0 {
+        t.Errorf("expected no releases, got %v", names)
+    }
+}
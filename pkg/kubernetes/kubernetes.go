@@ -13,6 +13,7 @@ import (
 
     "github.com/sirupsen/logrus"
     apiv1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/errors"
     metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
     "k8s.io/client-go/kubernetes"
     _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -20,6 +21,12 @@ import (
     "k8s.io/client-go/util/homedir"
 )
 
+// Define labels helmctl puts on created objects
+const (
+    ManagedByLabel = "app.kubernetes.io/managed-by"
+    ManagedByValue = "helmctl"
+)
+
 // GetKubernetesClient returns Kubernetes client
 func GetKubernetesClient(kubeconfigPath string) (*kubernetes.Clientset, error) {
     if kubeconfigPath == "" {
@@ -44,9 +51,7 @@ func GetKubernetesClient(kubeconfigPath string) (*kubernetes.Clientset, error) {
 
 // checkNamespace checks existence of Namespace and creates it if it's needed
 // kubernetes.Interface is used for mock in tests
-func CheckNamespace(clientset kubernetes.Interface, name string, dryrun bool) error {
-    ctx := context.Background()
-
+func CheckNamespace(ctx context.Context, clientset kubernetes.Interface, name string, dryrun bool) error {
     _, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
     if err == nil {
         logrus.Infof("Namespace %s exists", name)
@@ -54,12 +59,18 @@ func CheckNamespace(clientset kubernetes.Interface, name string, dryrun bool) er
     }
     newNamespace := &apiv1.Namespace{
         ObjectMeta: metav1.ObjectMeta{
-            Name: name,
+            Name:   name,
+            Labels: map[string]string{ManagedByLabel: ManagedByValue},
         },
     }
 
     if !dryrun {
         _, err = clientset.CoreV1().Namespaces().Create(ctx, newNamespace, metav1.CreateOptions{})
+        // namespace could be created by a release installed at the same time
+        if errors.IsAlreadyExists(err) {
+            logrus.Infof("Namespace %s exists", name)
+            return nil
+        }
         if err != nil {
             return fmt.Errorf("cannot create namespace %s : %v", name, err)
         }
@@ -69,3 +80,33 @@ func CheckNamespace(clientset kubernetes.Interface, name string, dryrun bool) er
 
     return nil
 }
+
+// DeleteNamespace deletes Namespace if it was created by CheckNamespace.
+// Namespaces without helmctl label are left untouched.
+func DeleteNamespace(ctx context.Context, clientset kubernetes.Interface, name string, dryrun bool) error {
+    ns, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+    if err != nil {
+        if errors.IsNotFound(err) {
+            logrus.Infof("Namespace %s does not exist", name)
+            return nil
+        }
+        return fmt.Errorf("cannot get namespace %s : %v", name, err)
+    }
+
+    if ns.Labels[ManagedByLabel] != ManagedByValue {
+        logrus.Infof("Namespace %s is not managed by helmctl, skip deletion", name)
+        return nil
+    }
+
+    if dryrun {
+        logrus.Infof("Namespace %s will be deleted", name)
+        return nil
+    }
+
+    if err := clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+        return fmt.Errorf("cannot delete namespace %s : %v", name, err)
+    }
+    logrus.Infof("Namespace %s was deleted", name)
+
+    return nil
+}
@@ -6,9 +6,13 @@ import (
     "time"
 
     "k8s.io/api/core/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
     "k8s.io/apimachinery/pkg/util/wait"
     "k8s.io/client-go/informers"
     "k8s.io/client-go/kubernetes/fake"
+    k8stesting "k8s.io/client-go/testing"
     "k8s.io/client-go/tools/cache"
 )
 
@@ -40,7 +44,7 @@ func TestCheckNamespace(t *testing.T) {
     // we send any events to it.
     cache.WaitForCacheSync(ctx.Done(), namespaceInformer.HasSynced)
 
-    err := CheckNamespace(client, "fake-namespace", false)
+    err := CheckNamespace(context.TODO(), client, "fake-namespace", false)
     if err != nil {
         t.Fatalf("error injecting namespace add: %v", err)
     }
@@ -52,3 +56,65 @@ func TestCheckNamespace(t *testing.T) {
         t.Error("Informer did not get the added namespace")
     }
 }
+
+// TestCheckNamespaceConcurrent tests creating the same namespace by releases
+// installed at the same time, both of them do not find it and create it
+func TestCheckNamespaceConcurrent(t *testing.T) {
+    client := fake.NewSimpleClientset()
+    client.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+        name := action.(k8stesting.GetAction).GetName()
+        return true, nil, apierrors.NewNotFound(v1.Resource("namespaces"), name)
+    })
+
+    for i := 0; i < 2; i++ {
+        if err := CheckNamespace(context.TODO(), client, "shared-namespace", false); err != nil {
+            t.Fatalf("attempt %d: cannot create namespace: %v", i+1, err)
+        }
+    }
+
+    namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(namespaces.Items) != 1 {
+        t.Errorf("expected 1 namespace, got %d", len(namespaces.Items))
+    }
+}
+
+// TestDeleteNamespace tests that only namespaces created by helmctl are deleted
+func TestDeleteNamespace(t *testing.T) {
+    ctx := context.Background()
+
+    client := fake.NewSimpleClientset(&v1.Namespace{
+        ObjectMeta: metav1.ObjectMeta{Name: "foreign-namespace"},
+    })
+
+    if err := CheckNamespace(context.TODO(), client, "fake-namespace", false); err != nil {
+        t.Fatalf("cannot create namespace: %v", err)
+    }
+
+    if err := DeleteNamespace(context.TODO(), client, "fake-namespace", true); err != nil {
+        t.Fatalf("cannot delete namespace in dry-run mode: %v", err)
+    }
+    if _, err := client.CoreV1().Namespaces().Get(ctx, "fake-namespace", metav1.GetOptions{}); err != nil {
+        t.Errorf("namespace was deleted in dry-run mode: %v", err)
+    }
+
+    if err := DeleteNamespace(context.TODO(), client, "fake-namespace", false); err != nil {
+        t.Fatalf("cannot delete namespace: %v", err)
+    }
+    if _, err := client.CoreV1().Namespaces().Get(ctx, "fake-namespace", metav1.GetOptions{}); err == nil {
+        t.Error("namespace created by helmctl was not deleted")
+    }
+
+    if err := DeleteNamespace(context.TODO(), client, "foreign-namespace", false); err != nil {
+        t.Fatalf("cannot process foreign namespace: %v", err)
+    }
+    if _, err := client.CoreV1().Namespaces().Get(ctx, "foreign-namespace", metav1.GetOptions{}); err != nil {
+        t.Errorf("namespace not managed by helmctl was deleted: %v", err)
+    }
+
+    if err := DeleteNamespace(context.TODO(), client, "missing-namespace", false); err != nil {
+        t.Errorf("missing namespace must be ignored: %v", err)
+    }
+}
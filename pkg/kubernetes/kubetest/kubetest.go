@@ -0,0 +1,41 @@
+// Package kubetest contains fixtures for tests of packages working with
+// Kubernetes, it is imported only by tests.
+package kubetest
+
+import (
+    "bytes"
+    "compress/gzip"
+    "encoding/base64"
+    "fmt"
+
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HelmReleaseSecret returns secret the same as helm creates for release revision.
+func HelmReleaseSecret(name, namespace string, version int, status, chartVersion string) *v1.Secret {
+    data := fmt.Sprintf(`{"name":%q,"namespace":%q,"version":%d,"info":{"status":%q},`+
+        `"chart":{"metadata":{"name":"chart","version":%q,"appVersion":"1.0"}}}`,
+        name, namespace, version, status, chartVersion)
+
+    // writing to buffer does not fail
+    var buf bytes.Buffer
+    w := gzip.NewWriter(&buf)
+    _, _ = w.Write([]byte(data))
+    w.Close()
+
+    return &v1.Secret{
+        ObjectMeta: metav1.ObjectMeta{
+            Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, version),
+            Namespace: namespace,
+            Labels: map[string]string{
+                "owner":   "helm",
+                "name":    name,
+                "status":  status,
+                "version": fmt.Sprint(version),
+            },
+        },
+        Type: "helm.sh/release.v1",
+        Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))},
+    }
+}